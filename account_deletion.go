@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+	// recentSignInWindow is how fresh the session of an account without
+	// a password must be to confirm its deletion.
+	recentSignInWindow = 10 * time.Minute
+	purgeInterval      = time.Hour
+)
+
+// deleteUser schedules the caller's account for deletion. It needs the
+// password (and second factor, if enabled) again, signs out everywhere,
+// and the account is purged once the grace period has passed. Signing in
+// before then cancels the deletion.
+func (cfg *apiConfig) deleteUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	if user.DeleteAfter.Valid {
+		respondWithError(w, http.StatusConflict, "account is already scheduled for deletion")
+		return
+	}
+	if cfg.checkLockout(w, r, user.Email) {
+		return
+	}
+	if user.HashedPassword == "" {
+		// Without a password, a fresh sign-in through the identity
+		// provider stands in for the confirmation.
+		if !cfg.signedInRecently(r, userID, sessionID(claims)) {
+			respondWithError(w, http.StatusForbidden, "sign in again to delete your account")
+			return
+		}
+	} else if err := cfg.Passwords.Check(user.HashedPassword, param.Password); err != nil {
+		cfg.recordLoginFailure(r, user.Email)
+		respondWithError(w, http.StatusUnauthorized, "Wrong Password!")
+		return
+	}
+	if user.TotpEnabledAt.Valid && !cfg.checkSecondFactor(r, user, param.Code, param.RecoveryCode) {
+		respondWithError(w, http.StatusUnauthorized, "Wrong code!")
+		return
+	}
+
+	delete_after := time.Now().Add(cfg.DeletionGracePeriod)
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	scheduled, err := qtx.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+		ID:          userID,
+		DeleteAfter: sql.NullTime{Time: delete_after, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	if scheduled == 0 {
+		respondWithError(w, http.StatusConflict, "account is already scheduled for deletion")
+		return
+	}
+	err = qtx.RevokeAllUserRefreshTokens(r.Context(), database.RevokeAllUserRefreshTokensParams{
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		UserID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	if err := qtx.RevokeAllPersonalAccessTokens(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	if err := qtx.RevokeAllOAuthGrants(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error scheduling account deletion")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.AccountDeletionRequest,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]time.Time{"delete_after": delete_after.UTC()},
+	})
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy account will be deleted",
+		Body: fmt.Sprintf("Your Chirpy account and everything you posted will be deleted on %s.\n\n"+
+			"Changed your mind? Sign in before then and the deletion is cancelled.\n", delete_after.UTC().Format("January 2, 2006")),
+	}, userID)
+
+	resp := struct {
+		DeleteAfter time.Time `json:"delete_after"`
+	}{DeleteAfter: delete_after}
+	respondWithJSON(w, http.StatusAccepted, resp)
+}
+
+// signedInRecently reports whether the session the request belongs to
+// started within recentSignInWindow.
+func (cfg *apiConfig) signedInRecently(r *http.Request, userID, session uuid.UUID) bool {
+	sessions, err := cfg.DB.ListUserSessions(r.Context(), userID)
+	if err != nil {
+		return false
+	}
+	for _, s := range sessions {
+		if s.FamilyID == session {
+			return time.Since(s.StartedAt) < recentSignInWindow
+		}
+	}
+	return false
+}
+
+// cancelUserDeletion is called on sign-in; coming back during the grace
+// period keeps the account.
+func (cfg *apiConfig) cancelUserDeletion(r *http.Request, user database.User) {
+	if !user.DeleteAfter.Valid {
+		return
+	}
+	cancelled, err := cfg.DB.CancelUserDeletion(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("error cancelling deletion of user %s: %v", user.ID, err)
+		return
+	}
+	if cancelled == 1 {
+		cfg.audit(r, audit.Event{
+			Type:      audit.AccountDeletionCancel,
+			ActorID:   user.ID,
+			SubjectID: user.ID,
+		})
+	}
+}
+
+// purgeDeletedUsers deletes accounts whose grace period has passed. Their
+// chirps are removed first like any deleted chirp, leaving tombstones
+// where others replied; sessions and tokens go with the accounts through
+// ON DELETE CASCADE, and the audit log keeps the IDs. Profile images live
+// outside the database and are deleted here.
+func (cfg *apiConfig) purgeDeletedUsers(ctx context.Context) {
+	// Newest first, so replies within a user's own threads are gone before
+	// the chirps they reply to. Chirps missed here lose their author with
+	// the account and are picked up on the next run.
+	chirps, err := cfg.DB.ListPurgeableChirps(ctx)
+	if err != nil {
+		log.Printf("error listing chirps of deleted accounts: %v", err)
+	}
+	for _, chirp := range chirps {
+		if err := cfg.removeChirp(ctx, chirp); err != nil {
+			log.Printf("error removing chirp %s of a deleted account: %v", chirp.ID, err)
+		}
+	}
+	rows, err := cfg.deleteAccounts(ctx)
+	if err != nil {
+		log.Printf("error purging deleted accounts: %v", err)
+		return
+	}
+	// Rechirps by others of the deleted chirps share nothing anymore.
+	if err := cfg.DB.DeleteOrphanedRechirps(ctx); err != nil {
+		log.Printf("error deleting orphaned rechirps: %v", err)
+	}
+	for _, row := range rows {
+		log.Printf("deleted account %s", row.ID)
+		for _, key := range []sql.NullString{row.AvatarKey, row.HeaderKey} {
+			if key.Valid {
+				cfg.deleteBlob(ctx, key.String)
+			}
+		}
+		if err := cfg.Audit.Record(ctx, audit.Event{Type: audit.AccountDelete, SubjectID: row.ID}); err != nil {
+			log.Printf("error recording audit event %s: %v", audit.AccountDelete, err)
+		}
+	}
+	if err := cfg.DB.DeleteExpiredDataExports(ctx); err != nil {
+		log.Printf("error deleting expired data exports: %v", err)
+	}
+	if err := cfg.DB.FailStaleDataExports(ctx, time.Now().Add(-staleDataExportAge)); err != nil {
+		log.Printf("error failing stale data exports: %v", err)
+	}
+	if err := cfg.DB.DeleteExpiredMutedWords(ctx); err != nil {
+		log.Printf("error deleting expired muted words: %v", err)
+	}
+	if err := cfg.DB.DeleteExpiredHandles(ctx); err != nil {
+		log.Printf("error deleting expired handle redirects: %v", err)
+	}
+	cfg.purgeMedia(ctx)
+}
+
+// deleteAccounts deletes the accounts whose grace period has passed. Their
+// follows are removed first, in the same transaction, so the counts of
+// the accounts they followed and were followed by stay right.
+func (cfg *apiConfig) deleteAccounts(ctx context.Context) ([]database.PurgeDeletedUsersRow, error) {
+	tx, err := cfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if err := qtx.DeleteFollowsOfDeletedUsers(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := qtx.PurgeDeletedUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rows, tx.Commit()
+}
+
+// runPurger calls purgeDeletedUsers every interval until ctx is done.
+func (cfg *apiConfig) runPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cfg.purgeDeletedUsers(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/mutes"
+	"github.com/google/uuid"
+)
+
+type UserRelation struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedWord struct {
+	ID        uuid.UUID  `json:"id"`
+	Word      string     `json:"word"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// loadFilter reads who blocked userID and what they muted.
+func (cfg *apiConfig) loadFilter(ctx context.Context, userID uuid.UUID) (*mutes.Filter, error) {
+	blockers, err := cfg.DB.ListBlockers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	muted, err := cfg.DB.ListMutes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	words, err := cfg.DB.ListMutedWords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	mutedIDs := make([]uuid.UUID, 0, len(muted))
+	for _, m := range muted {
+		mutedIDs = append(mutedIDs, m.UserID)
+	}
+	mutedWords := make([]string, 0, len(words))
+	for _, w := range words {
+		mutedWords = append(mutedWords, w.Word)
+	}
+	return mutes.NewFilter(blockers, mutedIDs, mutedWords), nil
+}
+
+// blockedBy reports whether authorID blocked userID.
+func (cfg *apiConfig) blockedBy(ctx context.Context, authorID, userID uuid.UUID) (bool, error) {
+	return cfg.DB.IsBlocked(ctx, database.IsBlockedParams{
+		BlockerID: authorID,
+		BlockedID: userID,
+	})
+}
+
+// viewerFilter returns the filter of the caller on endpoints that don't
+// require signing in. Anonymous requests get the nil filter, and so do
+// requests whose credentials don't authorize reading chirps: what anyone
+// may read stays readable.
+func (cfg *apiConfig) viewerFilter(r *http.Request) (*mutes.Filter, error) {
+	if r.Header.Get("Authorization") == "" {
+		return nil, nil
+	}
+	caller, err := cfg.authorize(r, auth.ScopeChirpsRead)
+	if err != nil {
+		return nil, nil
+	}
+	return cfg.loadFilter(r.Context(), caller.UserID)
+}
+
+// visible reports whether chirp is shown to the viewer of f. The chirp a
+// quote refers to is replaced by a placeholder if its author blocked the
+// viewer. Listings also leave out muted chirps.
+func visible(f *mutes.Filter, chirp *Chirp, listing bool) bool {
+	if f.Hides(chirp.User_id) {
+		return false
+	}
+	ref := chirp.ReferencedChirp
+	if ref != nil && f.Hides(ref.User_id) {
+		if chirp.Kind == chirpKindRechirp {
+			return false
+		}
+		chirp.ReferencedChirp = nil
+		chirp.ReferencedChirpUnavailable = true
+	}
+	if !listing {
+		return true
+	}
+	if f.Mutes(chirp.User_id, chirp.Body) {
+		return false
+	}
+	if chirp.Kind == chirpKindRechirp && chirp.ReferencedChirp != nil {
+		return !f.Mutes(ref.User_id, ref.Body)
+	}
+	return true
+}
+
+func filterChirps(f *mutes.Filter, chirps []Chirp, listing bool) []Chirp {
+	shown := chirps[:0]
+	for i := range chirps {
+		if visible(f, &chirps[i], listing) {
+			shown = append(shown, chirps[i])
+		}
+	}
+	return shown
+}
+
+// blockUser blocks the user in the path for the caller. Follows between
+// the two are removed in both directions; the blocked user can't follow
+// the caller again, reply to, quote, rechirp or react to their chirps,
+// or see them while signed in.
+func (cfg *apiConfig) blockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	if blockedID == caller.UserID {
+		respondWithError(w, http.StatusBadRequest, "you can't block yourself")
+		return
+	}
+	if _, err := cfg.DB.GetUserById(r.Context(), blockedID); err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error blocking user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	created, err := qtx.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: caller.UserID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error blocking user")
+		return
+	}
+	if created == 1 {
+		if _, err := unfollow(r.Context(), qtx, caller.UserID, blockedID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error blocking user")
+			return
+		}
+		if _, err := unfollow(r.Context(), qtx, blockedID, caller.UserID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error blocking user")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error blocking user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unblockUser(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	deleted, err := cfg.DB.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: caller.UserID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unblocking user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not blocked")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) listBlocks(w http.ResponseWriter, r *http.Request) {
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	rows, err := cfg.DB.ListBlocks(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving blocked users")
+		return
+	}
+	blocked := []UserRelation{}
+	for _, row := range rows {
+		blocked = append(blocked, UserRelation{UserID: row.UserID, CreatedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, http.StatusOK, blocked)
+}
+
+// muteUser leaves the chirps of the user in the path out of the caller's
+// timeline and chirp listings. Unlike a block, the muted user isn't told
+// and nothing else changes for them.
+func (cfg *apiConfig) muteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	if mutedID == caller.UserID {
+		respondWithError(w, http.StatusBadRequest, "you can't mute yourself")
+		return
+	}
+	if _, err := cfg.DB.GetUserById(r.Context(), mutedID); err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	err = cfg.DB.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: caller.UserID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error muting user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unmuteUser(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	deleted, err := cfg.DB.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: caller.UserID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unmuting user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not muted")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) listMutes(w http.ResponseWriter, r *http.Request) {
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	rows, err := cfg.DB.ListMutes(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving muted users")
+		return
+	}
+	muted := []UserRelation{}
+	for _, row := range rows {
+		muted = append(muted, UserRelation{UserID: row.UserID, CreatedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, http.StatusOK, muted)
+}
+
+// muteWord mutes a word or phrase for the caller, for expires_in_hours or
+// until it is removed. Muting a word again replaces its expiry.
+func (cfg *apiConfig) muteWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Word           string `json:"word"`
+		ExpiresInHours int    `json:"expires_in_hours"`
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	word, err := mutes.NormalizeWord(param.Word)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if param.ExpiresInHours < 0 {
+		respondWithError(w, http.StatusBadRequest, "expires_in_hours must not be negative")
+		return
+	}
+	var expires_at sql.NullTime
+	if param.ExpiresInHours > 0 {
+		expires_at = sql.NullTime{Time: time.Now().Add(time.Duration(param.ExpiresInHours) * time.Hour), Valid: true}
+	}
+	row, err := cfg.DB.UpsertMutedWord(r.Context(), database.UpsertMutedWordParams{
+		UserID:    caller.UserID,
+		Word:      word,
+		ExpiresAt: expires_at,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error muting word")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, mutedWordFromDB(row))
+}
+
+func mutedWordFromDB(row database.MutedWord) MutedWord {
+	return MutedWord{
+		ID:        row.ID,
+		Word:      row.Word,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: optionalTime(row.ExpiresAt),
+	}
+}
+
+func (cfg *apiConfig) listMutedWords(w http.ResponseWriter, r *http.Request) {
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	rows, err := cfg.DB.ListMutedWords(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving muted words")
+		return
+	}
+	words := []MutedWord{}
+	for _, row := range rows {
+		words = append(words, mutedWordFromDB(row))
+	}
+	respondWithJSON(w, http.StatusOK, words)
+}
+
+func (cfg *apiConfig) unmuteWord(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeBlocksWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	deleted, err := cfg.DB.DeleteMutedWord(r.Context(), database.DeleteMutedWordParams{
+		ID:     id,
+		UserID: caller.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unmuting word")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "muted word not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/chirptext"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEditWindow    = 30 * time.Minute
+	defaultRedEditWindow = 2 * time.Hour
+)
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// editChirp replaces the body of the caller's chirp. Authors can edit for
+// EditWindow after posting, Chirpy Red members for RedEditWindow. The
+// previous body is kept as a revision.
+func (cfg *apiConfig) editChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	param := parameters{}
+	err = decodeChirpJSON(w, r, &param)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+		return
+	}
+	if errors.Is(err, chirptext.ErrInvalidUTF8) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	chirpText, err := validateChirps(param.Body, cfg.maxChirpLength(user))
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	// The lock keeps concurrent edits from losing a revision.
+	chirp, err := qtx.LockChirp(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	if chirp.UserID.UUID != caller.UserID {
+		respondWithError(w, http.StatusForbidden, "user is not the author of the chirp, cant edit other users chirps")
+		return
+	}
+	if chirp.Kind == chirpKindRechirp {
+		respondWithError(w, http.StatusBadRequest, "rechirps have no text to edit")
+		return
+	}
+	if chirp.Kind == chirpKindQuote && strings.TrimSpace(chirpText) == "" {
+		respondWithError(w, http.StatusBadRequest, "a quote needs commentary, rechirp instead")
+		return
+	}
+	window := cfg.EditWindow
+	if user.IsChirpyRed {
+		window = cfg.RedEditWindow
+	}
+	if time.Since(chirp.CreatedAt) > window {
+		respondWithError(w, http.StatusForbidden, "the edit window for this chirp has closed")
+		return
+	}
+
+	if chirpText != chirp.Body {
+		err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID:   chirp.ID,
+			Body:      chirp.Body,
+			CreatedAt: chirp.UpdatedAt,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+			return
+		}
+		chirp, err = qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:   chirp.ID,
+			Body: chirpText,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+			return
+		}
+		if err := storeEntities(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+		return
+	}
+	resp, err := cfg.hydrateChirps(r.Context(), []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error editing chirp")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp[0])
+}
+
+// listChirpRevisions returns the previous bodies of a chirp, newest first.
+func (cfg *apiConfig) listChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving revisions")
+		return
+	}
+	chirp, err := cfg.DB.GetChirpById(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || filter.Hides(chirp.UserID.UUID) {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	rows, err := cfg.DB.ListChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving revisions")
+		return
+	}
+	revisions := []ChirpRevision{}
+	for _, row := range rows {
+		revisions = append(revisions, ChirpRevision{
+			ID:         row.ID,
+			Body:       row.Body,
+			CreatedAt:  row.CreatedAt,
+			ReplacedAt: row.ReplacedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, revisions)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/export"
+	"github.com/google/uuid"
+)
+
+const (
+	// dataExportTTL is how long a finished archive waits to be downloaded.
+	dataExportTTL     = 7 * 24 * time.Hour
+	dataExportTimeout = 10 * time.Minute
+	// dataExportStoreTimeout bounds storing the result, which gets its own
+	// context so a build that ran out of time is still marked failed.
+	dataExportStoreTimeout = 30 * time.Second
+	// staleDataExportAge is when a pending export is given up on; its
+	// build must have died with the process.
+	staleDataExportAge = 2 * dataExportTimeout
+
+	exportStatusPending = "pending"
+	exportStatusReady   = "ready"
+	exportStatusFailed  = "failed"
+)
+
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	Downloaded  bool       `json:"downloaded"`
+}
+
+func optionalTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// requestDataExport starts building an archive of the caller's data in
+// the background. Poll the returned export until it is ready, then
+// download it once.
+func (cfg *apiConfig) requestDataExport(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	data_export, err := cfg.DB.CreateDataExport(r.Context(), database.CreateDataExportParams{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(dataExportTTL),
+	})
+	// Only one export per user can be pending, which the insert leaves
+	// to data_exports_pending_idx.
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "an export is already being prepared")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating data export")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.DataExportRequest,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]uuid.UUID{"export_id": data_export.ID},
+	})
+	go cfg.buildDataExport(data_export.ID, userID)
+
+	w.Header().Set("Location", fmt.Sprintf("/api/exports/%s", data_export.ID))
+	respondWithJSON(w, http.StatusAccepted, DataExport{
+		ID:        data_export.ID,
+		CreatedAt: data_export.CreatedAt,
+		Status:    data_export.Status,
+		ExpiresAt: data_export.ExpiresAt,
+	})
+}
+
+func (cfg *apiConfig) buildDataExport(exportID, userID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+	defer cancel()
+	params := database.CompleteDataExportParams{ID: exportID, Status: exportStatusFailed}
+	archive, err := export.Collect(ctx, cfg.DB, userID, time.Now())
+	if err == nil {
+		var buf bytes.Buffer
+		if err = archive.WriteZip(&buf); err == nil {
+			params.Status = exportStatusReady
+			params.Archive = buf.Bytes()
+		}
+	}
+	if err != nil {
+		log.Printf("error building data export %s for user %s: %v", exportID, userID, err)
+	}
+	storeCtx, cancelStore := context.WithTimeout(context.Background(), dataExportStoreTimeout)
+	defer cancelStore()
+	if err := cfg.DB.CompleteDataExport(storeCtx, params); err != nil {
+		log.Printf("error storing data export %s for user %s: %v", exportID, userID, err)
+	}
+}
+
+func (cfg *apiConfig) getDataExport(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	row, err := cfg.DB.GetDataExport(r.Context(), database.GetDataExportParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "export not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, DataExport{
+		ID:          row.ID,
+		CreatedAt:   row.CreatedAt,
+		Status:      row.Status,
+		CompletedAt: optionalTime(row.CompletedAt),
+		ExpiresAt:   row.ExpiresAt,
+		Downloaded:  row.DownloadedAt.Valid,
+	})
+}
+
+// downloadDataExport sends the archive and deletes it; every export can be
+// downloaded once.
+func (cfg *apiConfig) downloadDataExport(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	archive, err := cfg.DB.ClaimDataExportArchive(r.Context(), database.ClaimDataExportArchiveParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "export is not ready, expired or was already downloaded")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving data export")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.DataExportDownload,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]uuid.UUID{"export_id": id},
+	})
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
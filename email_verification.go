@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// validateEmail accepts a bare address such as "user@example.com".
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%q is not a valid email address", email)
+	}
+	return nil
+}
+
+// sendMail delivers msg in the background so the request doesn't wait on
+// the mail server.
+func (cfg *apiConfig) sendMail(msg mailer.Message, userID uuid.UUID) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := cfg.Mailer.Send(ctx, msg); err != nil {
+			log.Printf("error sending %q to user %s: %v", msg.Subject, userID, err)
+		}
+	}()
+}
+
+// sendEmailVerification mails a confirmation link to email. Confirming it
+// marks the account verified and, if email differs from the current
+// address, switches the account to it.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.DB.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/app/verify-email?token=%s", cfg.BaseURL, url.QueryEscape(token))
+	cfg.sendMail(mailer.Message{
+		To:      email,
+		Subject: "Confirm your email address for Chirpy",
+		Body: fmt.Sprintf("Please confirm that %s is your email address by opening this link within %d hours:\n%s\n\n"+
+			"If you didn't sign up for Chirpy, you can ignore this email.\n", email, int(emailVerificationTTL.Hours()), link),
+	}, userID)
+	return nil
+}
+
+func (cfg *apiConfig) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err := decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error verifying email")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	verification, err := qtx.ConsumeEmailVerificationToken(r.Context(), auth.HashToken(param.Token))
+	if err == sql.ErrNoRows {
+		respondWithError(w, http.StatusBadRequest, "verification token invalid or expired")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error verifying email")
+		return
+	}
+	err = qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    verification.UserID,
+		Email: verification.Email,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusConflict, "email address is already in use")
+		return
+	}
+	err = qtx.InvalidateEmailVerificationTokens(r.Context(), verification.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error verifying email")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error verifying email")
+		return
+	}
+
+	user, err := cfg.DB.GetUserById(r.Context(), verification.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, User{
+		ID:         user.ID,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		Email:      user.Email,
+		Handle:     user.Handle.String,
+		Red:        user.IsChirpyRed,
+		IsVerified: user.VerifiedAt.Valid,
+		Role:       user.Role,
+	})
+}
+
+func (cfg *apiConfig) resendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	if user.VerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "email address is already verified")
+		return
+	}
+	if err := cfg.sendEmailVerification(r.Context(), user.ID, user.Email); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error sending verification email")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
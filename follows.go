@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/timeline"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFollowsPageSize = 50
+	maxFollowsPageSize     = 200
+	// followBackfill is how many recent chirps of a newly followed user
+	// are added to the follower's timeline.
+	followBackfill = 50
+)
+
+type FollowEntry struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowList struct {
+	Count int32         `json:"count"`
+	Users []FollowEntry `json:"users"`
+	// NextCursor continues with ?cursor=; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// followUser makes the caller follow the user in the path. Following
+// twice is a no-op; the counts only change when the follow is new.
+func (cfg *apiConfig) followUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeFollowsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	if followeeID == caller.UserID {
+		respondWithError(w, http.StatusBadRequest, "you can't follow yourself")
+		return
+	}
+	followee, err := cfg.DB.GetUserById(r.Context(), followeeID)
+	if err != nil || followee.DeleteAfter.Valid {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	blocked, err := cfg.blockedBy(r.Context(), followeeID, caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "you can't follow this user")
+		return
+	}
+	blocking, err := cfg.blockedBy(r.Context(), caller.UserID, followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		return
+	}
+	if blocking {
+		respondWithError(w, http.StatusConflict, "unblock this user to follow them")
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	created, err := qtx.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: caller.UserID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		return
+	}
+	if created == 1 {
+		err = qtx.AdjustFollowCounts(r.Context(), database.AdjustFollowCountsParams{
+			FollowerID: caller.UserID,
+			FolloweeID: followeeID,
+			Delta:      1,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error following user")
+			return
+		}
+		// Chirps of popular users are read at request time, so only the
+		// others need to be copied into the timeline.
+		if followee.FollowerCount < timeline.MaxFanOutFollowers {
+			err = qtx.BackfillTimeline(r.Context(), database.BackfillTimelineParams{
+				UserID:    caller.UserID,
+				AuthorID:  uuid.NullUUID{UUID: followeeID, Valid: true},
+				MaxChirps: followBackfill,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "error following user")
+				return
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollowUser stops the caller following the user in the path and drops
+// that user's chirps from the caller's timeline.
+func (cfg *apiConfig) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeFollowsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unfollowing user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	followed, err := unfollow(r.Context(), qtx, caller.UserID, followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unfollowing user")
+		return
+	}
+	if !followed {
+		respondWithError(w, http.StatusNotFound, "you don't follow this user")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unfollowing user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollow deletes the follow of followerID on followeeID, updates the
+// counts and drops the followee's chirps from the follower's timeline. It
+// reports whether there was a follow. A followee who drops back to
+// MaxFanOutFollowers is fanned out again from now on; the chirps posted
+// while they were read at request time are copied into the timelines of
+// the remaining followers.
+func unfollow(ctx context.Context, q *database.Queries, followerID, followeeID uuid.UUID) (bool, error) {
+	deleted, err := q.DeleteFollow(ctx, database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+	err = q.AdjustFollowCounts(ctx, database.AdjustFollowCountsParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		Delta:      -1,
+	})
+	if err != nil {
+		return false, err
+	}
+	followee, err := q.GetUserById(ctx, followeeID)
+	if err != nil {
+		return false, err
+	}
+	if followee.FollowerCount == timeline.MaxFanOutFollowers {
+		err = q.BackfillFollowerTimelines(ctx, database.BackfillFollowerTimelinesParams{
+			AuthorID:  followeeID,
+			MaxChirps: followBackfill,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	err = q.DeleteTimelineEntriesFromAuthor(ctx, database.DeleteTimelineEntriesFromAuthorParams{
+		UserID:   followerID,
+		AuthorID: followeeID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (cfg *apiConfig) listFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, true)
+}
+
+func (cfg *apiConfig) listFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, false)
+}
+
+// listFollows returns who follows a user, or whom the user follows, most
+// recent first, with the total count. Continue with
+// ?cursor=<next_cursor>.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultFollowsPageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxFollowsPageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+	var cursor timeline.Cursor
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = timeline.Decode(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	followedAt, afterID := cursor.Bounds()
+
+	resp := FollowList{Users: []FollowEntry{}}
+	if followers {
+		resp.Count = user.FollowerCount
+		rows, err := cfg.DB.ListFollowers(r.Context(), database.ListFollowersParams{
+			FolloweeID: userID,
+			CreatedAt:  followedAt,
+			FollowerID: afterID,
+			Limit:      int32(limit),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving followers")
+			return
+		}
+		for _, row := range rows {
+			resp.Users = append(resp.Users, FollowEntry{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+	} else {
+		resp.Count = user.FollowingCount
+		rows, err := cfg.DB.ListFollowing(r.Context(), database.ListFollowingParams{
+			FollowerID: userID,
+			CreatedAt:  followedAt,
+			FolloweeID: afterID,
+			Limit:      int32(limit),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error retrieving followed users")
+			return
+		}
+		for _, row := range rows {
+			resp.Users = append(resp.Users, FollowEntry{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+	}
+	if len(resp.Users) == limit {
+		last := resp.Users[len(resp.Users)-1]
+		resp.NextCursor = timeline.Cursor{CreatedAt: last.FollowedAt, ID: last.UserID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -9,4 +9,10 @@ require (
 	golang.org/x/crypto v0.38.0
 )
 
-require github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.2
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.25.0
+)
+
+require golang.org/x/sys v0.33.0 // indirect
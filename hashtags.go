@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/entities"
+	"github.com/LucaFe1337/Chipry/internal/handles"
+	"github.com/LucaFe1337/Chipry/internal/timeline"
+	"github.com/google/uuid"
+)
+
+// entityBackfillBatch is how many old chirps backfillEntities handles per
+// transaction.
+const entityBackfillBatch = 500
+
+const (
+	entityHashtag = "hashtag"
+	entityMention = "mention"
+	entityURL     = "url"
+)
+
+// Entities are the links in a chirp body. Start and End are code point
+// offsets into the body, End exclusive.
+type Entities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	// Mentions only lists handles that belong to a user.
+	Mentions []MentionEntity `json:"mentions"`
+	URLs     []URLEntity     `json:"urls"`
+}
+
+type HashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+type MentionEntity struct {
+	Handle string    `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+type URLEntity struct {
+	URL   string `json:"url"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+func emptyEntities() Entities {
+	return Entities{Hashtags: []HashtagEntity{}, Mentions: []MentionEntity{}, URLs: []URLEntity{}}
+}
+
+// storeEntities extracts the entities of chirp and replaces the stored
+// ones. Mentions are resolved to users here; handles taken later don't
+// turn old chirps into mentions of someone else.
+func storeEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpEntities(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	found := entities.Extract(chirp.Body)
+	for _, tag := range found.Hashtags {
+		err := q.CreateChirpEntity(ctx, database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        entityHashtag,
+			StartOffset: int32(tag.Start),
+			EndOffset:   int32(tag.End),
+			Value:       tag.Tag,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			Tag:       tag.Tag,
+			ChirpID:   chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, url := range found.URLs {
+		err := q.CreateChirpEntity(ctx, database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        entityURL,
+			StartOffset: int32(url.Start),
+			EndOffset:   int32(url.End),
+			Value:       url.URL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(found.Mentions) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(found.Mentions))
+	for _, mention := range found.Mentions {
+		names = append(names, handles.Normalize(mention.Handle))
+	}
+	resolved, err := q.ResolveHandles(ctx, names)
+	if err != nil {
+		return err
+	}
+	users := make(map[string]uuid.UUID, len(resolved))
+	for _, row := range resolved {
+		users[row.Handle] = row.UserID
+	}
+	for _, mention := range found.Mentions {
+		userID, ok := users[handles.Normalize(mention.Handle)]
+		if !ok {
+			continue
+		}
+		err := q.CreateChirpEntity(ctx, database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        entityMention,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+			Value:       mention.Handle,
+			UserID:      uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillEntities extracts the entities of chirps posted before they were
+// stored, entityBackfillBatch chirps per transaction, until none are left.
+// It runs once at startup; whatever an error leaves is done on the next.
+// Their mentions resolve to whoever has the handle at that point.
+func (cfg *apiConfig) backfillEntities(ctx context.Context) {
+	for {
+		done, err := cfg.backfillEntityBatch(ctx)
+		if err != nil {
+			log.Printf("error backfilling chirp entities: %v", err)
+			return
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// backfillEntityBatch extracts the entities of one batch and reports
+// whether it was the last.
+func (cfg *apiConfig) backfillEntityBatch(ctx context.Context) (bool, error) {
+	tx, err := cfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	ids, err := qtx.TakeEntityBackfill(ctx, entityBackfillBatch)
+	if err != nil || len(ids) == 0 {
+		return true, err
+	}
+	chirps, err := qtx.GetChirpsByIds(ctx, ids)
+	if err != nil {
+		return false, err
+	}
+	for _, chirp := range chirps {
+		if chirp.DeletedAt.Valid || chirp.Body == "" {
+			continue
+		}
+		if err := storeEntities(ctx, qtx, chirp); err != nil {
+			return false, err
+		}
+	}
+	return len(ids) < entityBackfillBatch, tx.Commit()
+}
+
+// loadEntities adds the stored entities to chirps.
+func (cfg *apiConfig) loadEntities(ctx context.Context, chirps []Chirp) error {
+	index := make(map[uuid.UUID][]int, len(chirps))
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for i, chirp := range chirps {
+		if chirp.Deleted || chirp.Body == "" {
+			continue
+		}
+		if _, ok := index[chirp.ID]; !ok {
+			ids = append(ids, chirp.ID)
+		}
+		index[chirp.ID] = append(index[chirp.ID], i)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := cfg.DB.ListChirpEntities(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		for _, i := range index[row.ChirpID] {
+			e := &chirps[i].Entities
+			switch row.Kind {
+			case entityHashtag:
+				e.Hashtags = append(e.Hashtags, HashtagEntity{Tag: row.Value, Start: row.StartOffset, End: row.EndOffset})
+			case entityMention:
+				e.Mentions = append(e.Mentions, MentionEntity{Handle: row.Value, UserID: row.UserID.UUID, Start: row.StartOffset, End: row.EndOffset})
+			case entityURL:
+				e.URLs = append(e.URLs, URLEntity{URL: row.Value, Start: row.StartOffset, End: row.EndOffset})
+			}
+		}
+	}
+	return nil
+}
+
+// getHashtagChirps returns the chirps with a hashtag, newest first.
+// Continue with ?cursor=<next_cursor>.
+func (cfg *apiConfig) getHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+	if !entities.ValidTag(tag) {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag")
+		return
+	}
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultTimelinePageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxTimelinePageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+	var cursor timeline.Cursor
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = timeline.Decode(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	createdAt, id := cursor.Bounds()
+
+	rows, err := cfg.DB.ListHashtagChirps(r.Context(), database.ListHashtagChirpsParams{
+		Tag:       tag,
+		CreatedAt: createdAt,
+		ID:        id,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
+	}
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	hydrated, err := cfg.hydrateChirps(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
+	}
+	resp := Timeline{Chirps: filterChirps(filter, hydrated, true)}
+	if len(chirps) == limit {
+		resp.NextCursor = timeline.CursorOf(chirps[len(chirps)-1]).Encode()
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,205 @@
+// Package audit records security events in a hash chain. Every event
+// stores the hash of the event before it, so editing or deleting an event
+// in the middle of the log breaks every hash after it.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+// Event types.
+const (
+	LoginSuccess        = "login.success"
+	LoginFailure        = "login.failure"
+	TokenRefresh        = "token.refresh"
+	TokenReuseDetected  = "token.reuse_detected"
+	TokenRevoke         = "token.revoke"
+	SessionRevoke       = "session.revoke"
+	UserUpdate          = "user.update"
+	UserUpgradeRed      = "user.upgrade_red"
+	ChirpDelete         = "chirp.delete"
+	AdminRoleChange     = "admin.role_change"
+	AdminUnlock         = "admin.unlock"
+	AdminReset          = "admin.reset"
+	PasswordReset       = "password.reset"
+	PersonalTokenCreate = "personal_token.create"
+
+	AccountDeletionRequest = "account.deletion_request"
+	AccountDeletionCancel  = "account.deletion_cancel"
+	AccountDelete          = "account.delete"
+	DataExportRequest      = "data_export.request"
+	DataExportDownload     = "data_export.download"
+)
+
+// GenesisHash is the previous hash of the first event.
+var GenesisHash = strings.Repeat("0", 64)
+
+// Event is what callers record. Data holds event details such as before
+// and after values; it must never contain secrets.
+type Event struct {
+	Type      string
+	ActorID   uuid.UUID
+	SubjectID uuid.UUID
+	IP        string
+	Data      interface{}
+}
+
+// Diff is the conventional Data of events that change something.
+type Diff struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// Hash computes the chain hash of a stored event from its content and
+// the hash of its predecessor.
+func Hash(event database.AuditEvent) (string, error) {
+	canonical, err := json.Marshal([]interface{}{
+		event.PrevHash,
+		event.ID.String(),
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		event.EventType,
+		event.ActorID,
+		event.SubjectID,
+		event.Ip,
+		event.Data,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ErrChainBroken is returned by Verify when the log was tampered with.
+var ErrChainBroken = errors.New("audit log hash chain is broken")
+
+// VerifyChain checks events, which must be consecutive and in order, and
+// returns the hash of the last one. prevHash is the hash of the event
+// before the first one.
+func VerifyChain(prevHash string, events []database.AuditEvent) (string, error) {
+	for _, event := range events {
+		if event.PrevHash != prevHash {
+			return "", fmt.Errorf("%w: event %d does not follow its predecessor", ErrChainBroken, event.Seq)
+		}
+		hash, err := Hash(event)
+		if err != nil {
+			return "", err
+		}
+		if hash != event.Hash {
+			return "", fmt.Errorf("%w: event %d was modified", ErrChainBroken, event.Seq)
+		}
+		prevHash = hash
+	}
+	return prevHash, nil
+}
+
+// Logger appends events to the audit_events table.
+type Logger struct {
+	DB   *database.Queries
+	Conn *sql.DB
+	Now  func() time.Time
+}
+
+func NewLogger(db *database.Queries, conn *sql.DB) *Logger {
+	return &Logger{DB: db, Conn: conn, Now: time.Now}
+}
+
+// Record appends an event. Appends are serialized with an advisory lock
+// so that every event links to the one committed right before it.
+func (l *Logger) Record(ctx context.Context, e Event) error {
+	data := json.RawMessage("{}")
+	if e.Data != nil {
+		encoded, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		data = encoded
+	}
+	event := database.AuditEvent{
+		ID: uuid.New(),
+		// Postgres keeps microseconds; hash what will be read back.
+		CreatedAt: l.Now().UTC().Truncate(time.Microsecond),
+		EventType: e.Type,
+		ActorID:   nullUUID(e.ActorID),
+		SubjectID: nullUUID(e.SubjectID),
+		Ip:        e.IP,
+		Data:      data,
+	}
+
+	tx, err := l.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := l.DB.WithTx(tx)
+	if err := qtx.LockAuditLog(ctx); err != nil {
+		return err
+	}
+	event.PrevHash, err = qtx.GetLastAuditHash(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		event.PrevHash = GenesisHash
+	} else if err != nil {
+		return err
+	}
+	event.Hash, err = Hash(event)
+	if err != nil {
+		return err
+	}
+	_, err = qtx.InsertAuditEvent(ctx, database.InsertAuditEventParams{
+		ID:        event.ID,
+		CreatedAt: event.CreatedAt,
+		EventType: event.EventType,
+		ActorID:   event.ActorID,
+		SubjectID: event.SubjectID,
+		Ip:        event.Ip,
+		Data:      event.Data,
+		PrevHash:  event.PrevHash,
+		Hash:      event.Hash,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Verify walks the whole log and reports the number of events checked.
+// The error wraps ErrChainBroken if an event was modified or removed.
+func (l *Logger) Verify(ctx context.Context) (int, error) {
+	const pageSize = 1000
+	prevHash := GenesisHash
+	seq := int64(0)
+	checked := 0
+	for {
+		events, err := l.DB.ListAuditEventsAfter(ctx, database.ListAuditEventsAfterParams{
+			Seq:   seq,
+			Limit: pageSize,
+		})
+		if err != nil {
+			return checked, err
+		}
+		prevHash, err = VerifyChain(prevHash, events)
+		if err != nil {
+			return checked, err
+		}
+		checked += len(events)
+		if len(events) < pageSize {
+			return checked, nil
+		}
+		seq = events[len(events)-1].Seq
+	}
+}
@@ -3,6 +3,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -12,20 +13,16 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// HashPassword hashes with Argon2id and the default parameters.
 func HashPassword(password string) (string, error) {
-	hashed_password, err := bcrypt.GenerateFromPassword([]byte(password), 10)
-	if err != nil {
-		return "", err
-	}
-	return string(hashed_password), nil
+	return defaultPasswordHasher.Hash(password)
 }
 
+// CheckPassword verifies Argon2id as well as legacy bcrypt hashes.
 func CheckPassword(hash, password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err
+	return defaultPasswordHasher.Check(hash, password)
 }
 
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
@@ -106,6 +103,13 @@ func MakeRefreshToken() (string, error) {
 	return refresh_token, nil
 }
 
+// HashToken returns the SHA-256 digest of a random token, for tokens that
+// are stored server side but must not be usable if the table leaks.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetAPIKey(headers http.Header) (string, error) {
 	authorization := headers.Get("Authorization")
 	if authorization == "" {
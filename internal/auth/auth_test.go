@@ -88,3 +88,13 @@ func TestGetBearerToken_emptyToken(t *testing.T) {
 		t.Fatal(("Expected error for missing Authorization"))
 	}
 }
+
+func TestHashToken_Deterministic(t *testing.T) {
+	token, _ := MakeRefreshToken()
+	if HashToken(token) != HashToken(token) {
+		t.Fatal("Expected equal hashes for the same token")
+	}
+	if HashToken(token) == token {
+		t.Fatal("Expected hash to differ from the token")
+	}
+}
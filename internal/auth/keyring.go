@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+
+	tokenIssuer = "chirpy"
+
+	// TokenUseMFA marks the short-lived token handed out between the
+	// password and the second factor of a login.
+	TokenUseMFA = "mfa"
+	// TokenUseOIDCState marks the cookie that carries state, nonce and
+	// PKCE verifier through an external OpenID Connect login.
+	TokenUseOIDCState = "oidc_state"
+)
+
+// SigningKey is one asymmetric key held by a Keyring. Retiring keys are
+// still published and accepted for verification but never used to sign.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Retiring  bool
+}
+
+// Keyring signs access tokens with the first active asymmetric key and
+// verifies them with any known key, selected by the "kid" header.
+// HS256 tokens signed with the legacy secret keep validating until
+// LegacyUntil (forever if zero), so existing tokens survive the migration.
+// Without any asymmetric key the keyring falls back to signing HS256.
+type Keyring struct {
+	mu           sync.RWMutex
+	keys         []SigningKey
+	legacySecret string
+	legacyUntil  time.Time
+}
+
+// Claims are the claims carried by Chirpy access tokens. SessionID ties
+// the token to the refresh token family of the login that issued it.
+// TokenUse is empty for access tokens and names the purpose of any other
+// token signed by the keyring, so those can never pass as access tokens.
+// Tokens issued to OAuth clients carry the client and the granted scope.
+// Role is the user's role when the token was issued.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+	TokenUse  string `json:"token_use,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// NewClaims returns access token claims for userID expiring after expiresIn.
+func NewClaims(userID uuid.UUID, expiresIn time.Duration) Claims {
+	now := time.Now().UTC()
+	claims := Claims{}
+	claims.Issuer = tokenIssuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expiresIn))
+	claims.Subject = userID.String()
+	return claims
+}
+
+// UserID returns the subject of the token as a user ID.
+func (c Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+func NewKeyring(legacySecret string, legacyUntil time.Time) *Keyring {
+	return &Keyring{
+		legacySecret: legacySecret,
+		legacyUntil:  legacyUntil,
+	}
+}
+
+// AddKey registers a private key. The key ID is the RFC 7638 thumbprint
+// of the public key, so it is stable across restarts and replicas.
+func (k *Keyring) AddKey(private crypto.Signer, retiring bool) (SigningKey, error) {
+	var alg string
+	switch private.Public().(type) {
+	case *rsa.PublicKey:
+		alg = AlgRS256
+	case ed25519.PublicKey:
+		alg = AlgEdDSA
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type %T", private)
+	}
+	jwk, err := publicJWK(private.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+	key := SigningKey{
+		ID:        jwk.thumbprint(),
+		Algorithm: alg,
+		Private:   private,
+		Retiring:  retiring,
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, existing := range k.keys {
+		if existing.ID == key.ID {
+			return SigningKey{}, fmt.Errorf("key %s already in keyring", key.ID)
+		}
+	}
+	k.keys = append(k.keys, key)
+	return key, nil
+}
+
+// ParsePrivateKeyPEM reads a PKCS#8 or PKCS#1 encoded private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %v", err)
+	}
+	return key, nil
+}
+
+func (k *Keyring) signingKey() (SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if !key.Retiring {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (k *Keyring) lookup(kid string) (SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (k *Keyring) acceptsLegacy() bool {
+	if k.legacySecret == "" {
+		return false
+	}
+	return k.legacyUntil.IsZero() || time.Now().Before(k.legacyUntil)
+}
+
+// Sign signs arbitrary claims with the active key.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key, ok := k.signingKey()
+	if !ok {
+		if k.legacySecret == "" {
+			return "", errors.New("keyring has no signing key")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(k.legacySecret))
+	}
+
+	var method jwt.SigningMethod = jwt.SigningMethodRS256
+	if key.Algorithm == AlgEdDSA {
+		method = jwt.SigningMethodEdDSA
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Parse verifies tokenString and decodes it into claims.
+func (k *Keyring) Parse(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, k.keyFunc,
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA, AlgHS256}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("token was not valid")
+	}
+	return nil
+}
+
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == AlgHS256 {
+		if !k.acceptsLegacy() {
+			return nil, errors.New("HS256 tokens are no longer accepted")
+		}
+		return []byte(k.legacySecret), nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("key %s does not sign %s", kid, token.Method.Alg())
+	}
+	return key.Private.Public(), nil
+}
+
+// MakeJWT issues an access token for userID.
+func (k *Keyring) MakeJWT(userID uuid.UUID, expiresIn time.Duration) (string, error) {
+	return k.Sign(NewClaims(userID, expiresIn))
+}
+
+// ValidateJWT verifies an access token and returns its subject.
+func (k *Keyring) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := k.ParseClaims(tokenString)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("an Error has ocurred: %v", err)
+	}
+	userID, _ := claims.UserID()
+	return userID, nil
+}
+
+// ParseClaims verifies an access token and returns all of its claims.
+func (k *Keyring) ParseClaims(tokenString string) (Claims, error) {
+	return k.ParseClaimsFor(tokenString, "")
+}
+
+// ParseClaimsFor verifies a token issued for the given use.
+func (k *Keyring) ParseClaimsFor(tokenString, use string) (Claims, error) {
+	var claims Claims
+	if err := k.Parse(tokenString, &claims); err != nil {
+		return Claims{}, err
+	}
+	if claims.TokenUse != use {
+		return Claims{}, fmt.Errorf("token is meant for %q, not %q", claims.TokenUse, use)
+	}
+	if _, err := claims.UserID(); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every asymmetric key, including
+// retiring ones, so other services can verify tokens still in flight.
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range k.keys {
+		jwk, err := publicJWK(key.Private.Public())
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.ID
+		jwk.Use = "sig"
+		jwk.Alg = key.Algorithm
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func publicJWK(public crypto.PublicKey) (JWK, error) {
+	enc := base64.RawURLEncoding
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   enc.EncodeToString(pub.N.Bytes()),
+			E:   enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   enc.EncodeToString(pub),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported public key type %T", public)
+}
+
+// PublicKey decodes a JWK published by another issuer. RSA, EC P-256
+// and Ed25519 keys are supported.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	enc := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := enc.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding RSA modulus: %v", err)
+		}
+		e, err := enc.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding RSA exponent: %v", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := enc.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding EC x: %v", err)
+		}
+		y, err := enc.DecodeString(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decoding EC y: %v", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 coordinates")
+		}
+		point := append([]byte{4}, append(x, y...)...)
+		key, err := ecdh.P256().NewPublicKey(point)
+		if err != nil {
+			return nil, fmt.Errorf("invalid P-256 point: %v", err)
+		}
+		return ecdsaPublicKey(key)
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := enc.DecodeString(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding Ed25519 key: %v", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key length")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
+
+// ecdsaPublicKey converts a point validated by crypto/ecdh into the
+// ecdsa form the JWT library verifies with.
+func ecdsaPublicKey(key *ecdh.PublicKey) (*ecdsa.PublicKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA key")
+	}
+	return pub, nil
+}
+
+// thumbprint computes the RFC 7638 thumbprint over the required members,
+// which must appear in lexicographic order.
+func (j JWK) thumbprint() string {
+	var canonical string
+	switch j.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, j.Crv, j.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return key
+}
+
+func TestKeyring_RS256RoundTrip(t *testing.T) {
+	keyring := NewKeyring("", time.Time{})
+	key, err := keyring.AddKey(newRSAKey(t), false)
+	if err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	userID := uuid.New()
+	token, err := keyring.MakeJWT(userID, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified failed: %v", err)
+	}
+	if parsed.Header["kid"] != key.ID || parsed.Method.Alg() != AlgRS256 {
+		t.Errorf("Expected RS256 with kid %s, got %v with %v", key.ID, parsed.Method.Alg(), parsed.Header["kid"])
+	}
+
+	returnedID, err := keyring.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT failed: %v", err)
+	}
+	if returnedID != userID {
+		t.Errorf("Expected userID %v, got %v", userID, returnedID)
+	}
+}
+
+func TestKeyring_EdDSARoundTrip(t *testing.T) {
+	keyring := NewKeyring("", time.Time{})
+	if _, err := keyring.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	userID := uuid.New()
+	token, err := keyring.MakeJWT(userID, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+	returnedID, err := keyring.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("ValidateJWT failed: %v", err)
+	}
+	if returnedID != userID {
+		t.Errorf("Expected userID %v, got %v", userID, returnedID)
+	}
+}
+
+func TestKeyring_RetiringKeyStillValidates(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	before := NewKeyring("", time.Time{})
+	if _, err := before.AddKey(oldKey, false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	token, err := before.MakeJWT(uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+
+	after := NewKeyring("", time.Time{})
+	if _, err := after.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if _, err := after.AddKey(oldKey, true); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if _, err := after.ValidateJWT(token); err != nil {
+		t.Fatalf("Expected token signed by retiring key to validate, got %v", err)
+	}
+	if len(after.JWKS().Keys) != 2 {
+		t.Errorf("Expected retiring key to stay published, got %d keys", len(after.JWKS().Keys))
+	}
+}
+
+func TestKeyring_UnknownKeyRejected(t *testing.T) {
+	signer := NewKeyring("", time.Time{})
+	if _, err := signer.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	token, err := signer.MakeJWT(uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+
+	verifier := NewKeyring("", time.Time{})
+	if _, err := verifier.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if _, err := verifier.ValidateJWT(token); err == nil {
+		t.Fatal("Expected error for token signed by unknown key, got nil")
+	}
+}
+
+func TestKeyring_LegacyHS256Window(t *testing.T) {
+	secret := "supersecret"
+	userID := uuid.New()
+	token, err := MakeJWT(userID, secret, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+
+	open := NewKeyring(secret, time.Now().Add(time.Hour))
+	if _, err := open.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	returnedID, err := open.ValidateJWT(token)
+	if err != nil {
+		t.Fatalf("Expected legacy token to validate during migration, got %v", err)
+	}
+	if returnedID != userID {
+		t.Errorf("Expected userID %v, got %v", userID, returnedID)
+	}
+
+	closed := NewKeyring(secret, time.Now().Add(-time.Hour))
+	if _, err := closed.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if _, err := closed.ValidateJWT(token); err == nil {
+		t.Fatal("Expected error for legacy token after migration window, got nil")
+	}
+}
+
+func TestKeyring_FallsBackToHS256(t *testing.T) {
+	secret := "supersecret"
+	keyring := NewKeyring(secret, time.Time{})
+	token, err := keyring.MakeJWT(uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT failed: %v", err)
+	}
+	if _, err := ValidateJWT(token, secret); err != nil {
+		t.Fatalf("Expected HS256 token without asymmetric keys, got %v", err)
+	}
+}
+
+func TestKeyring_JWKSOmitsPrivateMaterial(t *testing.T) {
+	keyring := NewKeyring("", time.Time{})
+	if _, err := keyring.AddKey(newRSAKey(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if _, err := keyring.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	set := keyring.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(set.Keys))
+	}
+	for _, key := range set.Keys {
+		if key.Kid == "" || key.Use != "sig" {
+			t.Errorf("Expected kid and use=sig, got %+v", key)
+		}
+		if strings.Contains(key.Kty, "oct") {
+			t.Errorf("Symmetric key must never be published: %+v", key)
+		}
+	}
+	if set.Keys[0].Kty != "RSA" || set.Keys[1].Kty != "OKP" {
+		t.Errorf("Unexpected key types %s and %s", set.Keys[0].Kty, set.Keys[1].Kty)
+	}
+}
+
+func TestKeyring_TokenUseSeparatesTokens(t *testing.T) {
+	keyring := NewKeyring("", time.Time{})
+	if _, err := keyring.AddKey(newEd25519Key(t), false); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	claims := NewClaims(uuid.New(), time.Minute)
+	claims.TokenUse = TokenUseMFA
+	token, err := keyring.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := keyring.ValidateJWT(token); err == nil {
+		t.Fatal("Expected MFA token to be rejected as access token, got nil")
+	}
+	if _, err := keyring.ParseClaimsFor(token, TokenUseMFA); err != nil {
+		t.Fatalf("Expected MFA token to parse for its use, got %v", err)
+	}
+}
+
+func TestJWK_PublicKeyRoundTrip(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	edKey := newEd25519Key(t)
+	for _, public := range []interface{}{rsaKey.Public(), edKey.Public()} {
+		jwk, err := publicJWK(public)
+		if err != nil {
+			t.Fatalf("publicJWK failed: %v", err)
+		}
+		decoded, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("PublicKey failed: %v", err)
+		}
+		if !decoded.(interface{ Equal(crypto.PublicKey) bool }).Equal(public) {
+			t.Errorf("Decoded %s key does not match the original", jwk.Kty)
+		}
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	enc := base64.RawURLEncoding
+	jwk := JWK{Kty: "EC", Crv: "P-256", X: enc.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))), Y: enc.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32)))}
+	decoded, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey failed for EC key: %v", err)
+	}
+	if !ecKey.PublicKey.Equal(decoded) {
+		t.Error("Decoded EC key does not match the original")
+	}
+
+	jwk.Y = enc.EncodeToString(make([]byte, 32))
+	if _, err := jwk.PublicKey(); err == nil {
+		t.Error("Expected error for point not on the curve, got nil")
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match")
+	ErrUnknownHash      = errors.New("unknown password hash format")
+)
+
+// Hasher implements one password hashing algorithm. Hashes are stored as
+// self-describing strings so the algorithm and its parameters can change
+// without invalidating existing passwords.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch if password doesn't match.
+	Verify(encoded, password string) error
+	// Handles reports whether encoded was produced by this algorithm.
+	Handles(encoded string) bool
+	// Outdated reports whether encoded uses weaker parameters than the
+	// hasher is configured with.
+	Outdated(encoded string) bool
+}
+
+// Argon2idParams tune the cost of Argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the OWASP recommendation for Argon2id.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher stores hashes in PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Params.Memory, h.Params.Iterations, h.Params.Parallelism,
+		enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Verify(encoded, password string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h Argon2idHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h Argon2idHasher) Outdated(encoded string) bool {
+	params, salt, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.Params.Memory ||
+		params.Iterations < h.Params.Iterations ||
+		params.Parallelism != h.Params.Parallelism ||
+		params.KeyLength < h.Params.KeyLength ||
+		uint32(len(salt)) < h.Params.SaltLength
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idParams{}, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2 salt: %v", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2 hash: %v", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// BcryptHasher verifies the hashes Chirpy stored before Argon2id. Bcrypt
+// only looks at the first 72 bytes of a password.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h BcryptHasher) Verify(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h BcryptHasher) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// PasswordHasher hashes new passwords with Preferred and verifies hashes
+// made by Preferred or any of the Legacy hashers.
+type PasswordHasher struct {
+	Preferred Hasher
+	Legacy    []Hasher
+}
+
+// NewPasswordHasher prefers Argon2id with params and still accepts bcrypt.
+func NewPasswordHasher(params Argon2idParams) *PasswordHasher {
+	return &PasswordHasher{
+		Preferred: Argon2idHasher{Params: params},
+		Legacy:    []Hasher{BcryptHasher{Cost: 10}},
+	}
+}
+
+func (p *PasswordHasher) Hash(password string) (string, error) {
+	return p.Preferred.Hash(password)
+}
+
+// Check returns nil if password matches encoded.
+func (p *PasswordHasher) Check(encoded, password string) error {
+	for _, h := range append([]Hasher{p.Preferred}, p.Legacy...) {
+		if h.Handles(encoded) {
+			return h.Verify(encoded, password)
+		}
+	}
+	return ErrUnknownHash
+}
+
+// NeedsRehash reports whether encoded should be replaced by a fresh hash
+// the next time the plain password is known, i.e. after a login.
+func (p *PasswordHasher) NeedsRehash(encoded string) bool {
+	return !p.Preferred.Handles(encoded) || p.Preferred.Outdated(encoded)
+}
+
+var defaultPasswordHasher = NewPasswordHasher(DefaultArgon2idParams)
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestPasswordHasher_Argon2idRoundTrip(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Expected PHC encoded argon2id hash, got %s", hash)
+	}
+	if err := hasher.Check(hash, "correct horse"); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if err := hasher.Check(hash, "wrong horse"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("Expected ErrPasswordMismatch, got %v", err)
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Error("Fresh hash should not need a rehash")
+	}
+}
+
+func TestPasswordHasher_LongPasswordsAreNotTruncated(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	prefix := strings.Repeat("a", 72)
+	hash, err := hasher.Hash(prefix + "1")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if err := hasher.Check(hash, prefix+"2"); err == nil {
+		t.Fatal("Expected passwords differing after 72 bytes to mismatch")
+	}
+}
+
+func TestPasswordHasher_VerifiesLegacyBcrypt(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	legacy, err := BcryptHasher{Cost: 4}.Hash("hunter2")
+	if err != nil {
+		t.Fatalf("bcrypt Hash failed: %v", err)
+	}
+	if err := hasher.Check(legacy, "hunter2"); err != nil {
+		t.Fatalf("Expected legacy bcrypt hash to verify, got %v", err)
+	}
+	if err := hasher.Check(legacy, "hunter3"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("Expected ErrPasswordMismatch, got %v", err)
+	}
+	if !hasher.NeedsRehash(legacy) {
+		t.Error("Expected bcrypt hash to need a rehash")
+	}
+}
+
+func TestPasswordHasher_RehashWhenParamsIncrease(t *testing.T) {
+	weak := NewPasswordHasher(testArgon2idParams)
+	hash, err := weak.Hash("hunter2")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	if !NewPasswordHasher(stronger).NeedsRehash(hash) {
+		t.Error("Expected hash with fewer iterations to need a rehash")
+	}
+	if err := NewPasswordHasher(stronger).Check(hash, "hunter2"); err != nil {
+		t.Errorf("Old parameters must still verify, got %v", err)
+	}
+}
+
+func TestPasswordHasher_RejectsUnknownHashes(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	for _, hash := range []string{"", "unset", "$argon2id$v=19$garbage", "plaintext"} {
+		if err := hasher.Check(hash, hash); err == nil {
+			t.Errorf("Expected error for hash %q, got nil", hash)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeProfileWrite = "profile:write"
+	ScopeFollowsWrite = "follows:write"
+	ScopeBlocksWrite  = "blocks:write"
+	ScopeProfileEdit  = "profile:edit"
+
+	// PersonalAccessTokenPrefix marks personal access tokens so they can be
+	// told apart from JWTs without parsing, and found by secret scanners.
+	PersonalAccessTokenPrefix = "chirpy_pat_"
+)
+
+// KnownScopes lists every scope a token can be granted.
+var KnownScopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfileWrite, ScopeFollowsWrite, ScopeBlocksWrite, ScopeProfileEdit}
+
+// ValidateScopes checks that scopes is non-empty and only names known scopes.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !HasScope(KnownScopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether scope is among granted.
+func HasScope(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// MakePersonalAccessToken returns a new random personal access token.
+// Only its HashToken digest should be stored.
+func MakePersonalAccessToken() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + hex.EncodeToString(key), nil
+}
+
+// IsPersonalAccessToken reports whether token looks like a personal access token.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
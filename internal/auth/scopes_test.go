@@ -0,0 +1,35 @@
+package auth
+
+import "testing"
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeChirpsRead, ScopeChirpsWrite}); err != nil {
+		t.Fatalf("ValidateScopes failed: %v", err)
+	}
+	if err := ValidateScopes(nil); err == nil {
+		t.Fatal("Expected error for empty scopes, got nil")
+	}
+	if err := ValidateScopes([]string{"admin:everything"}); err == nil {
+		t.Fatal("Expected error for unknown scope, got nil")
+	}
+}
+
+func TestMakePersonalAccessToken(t *testing.T) {
+	first, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("MakePersonalAccessToken failed: %v", err)
+	}
+	second, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("MakePersonalAccessToken failed: %v", err)
+	}
+	if !IsPersonalAccessToken(first) {
+		t.Errorf("Expected %q to carry the %q prefix", first, PersonalAccessTokenPrefix)
+	}
+	if first == second {
+		t.Error("Expected two tokens to differ")
+	}
+	if IsPersonalAccessToken("eyJhbGciOiJFZERTQSJ9.e30.sig") {
+		t.Error("Expected a JWT not to be taken for a personal access token")
+	}
+}
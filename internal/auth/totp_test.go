@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for SHA1, truncated to six digits.
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("At %d expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestValidateTOTP_AllowsDriftAndRejectsReplay(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+	now := time.Now()
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+
+	step, ok := ValidateTOTP(secret, previous, now, 0)
+	if !ok {
+		t.Fatal("Expected code from the previous step to validate")
+	}
+	if _, ok := ValidateTOTP(secret, previous, now, step); ok {
+		t.Fatal("Expected replayed code to be rejected")
+	}
+
+	stale, _ := TOTPCode(secret, TOTPStep(now)-3)
+	if _, ok := ValidateTOTP(secret, stale, now, 0); ok {
+		t.Fatal("Expected code outside the drift window to be rejected")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Chirpy", "user@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Chirpy:user@example.com?") {
+		t.Errorf("Unexpected label in %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=Chirpy") {
+		t.Errorf("Missing secret or issuer in %s", uri)
+	}
+}
+
+func TestGenerateRecoveryCodes_Unique(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("Unexpected code format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("Duplicate code %q", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(strings.ToUpper(code)) != NormalizeRecoveryCode(code) {
+			t.Errorf("Normalization should ignore case for %q", code)
+		}
+	}
+}
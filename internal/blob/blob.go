@@ -0,0 +1,135 @@
+// Package blob stores uploaded files such as profile images. Keys are
+// slash-separated paths like "avatars/<user>/<hash>.jpg".
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Object is a stored blob being read. Close Body when done.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// Store keeps blobs by key. LocalStore suits a single instance; with
+// several replicas use S3Store so they share the files.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get returns ErrNotFound if there is no blob with key.
+	Get(ctx context.Context, key string) (Object, error)
+	// Delete succeeds if there is no blob with key.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key is a clean relative path of lower case
+// letters, digits, dots, dashes and underscores.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 512 || path.Clean(key) != key || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." || strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("/._-", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalStore keeps blobs as files below Dir.
+type LocalStore struct {
+	Dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{Dir: dir}, nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes data to a temporary file first, so readers never see a
+// partial blob.
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get derives the content type from the extension of key.
+func (s *LocalStore) Get(ctx context.Context, key string) (Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return Object{}, ErrNotFound
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return Object{}, err
+	}
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return Object{Body: f, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	cases := map[string]bool{
+		"avatars/3f2a/0123abcd.jpg": true,
+		"a":                         true,
+		"":                          false,
+		"/avatars/a.jpg":            false,
+		"avatars/../secret":         false,
+		"avatars//a.jpg":            false,
+		"avatars/.hidden":           false,
+		"Avatars/a.jpg":             false,
+		"avatars/a b.jpg":           false,
+		"avatars/a.jpg/":            false,
+	}
+	for key, want := range cases {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// testStore runs the behaviour every Store must have.
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	if _, err := store.Get(ctx, "avatars/u/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing blob: %v, want ErrNotFound", err)
+	}
+	if err := store.Put(ctx, "avatars/u/abc.png", "image/png", []byte("png data")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	obj, err := store.Get(ctx, "avatars/u/abc.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	if string(data) != "png data" || obj.ContentType != "image/png" || obj.Size != int64(len(data)) {
+		t.Errorf("Get = %q, %q, %d", data, obj.ContentType, obj.Size)
+	}
+	if err := store.Delete(ctx, "avatars/u/abc.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "avatars/u/abc.png"); err != nil {
+		t.Fatalf("Delete of a missing blob: %v", err)
+	}
+	if _, err := store.Get(ctx, "avatars/u/abc.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if err := store.Put(ctx, "../escape", "text/plain", nil); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put with an invalid key: %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
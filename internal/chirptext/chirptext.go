@@ -0,0 +1,81 @@
+// Package chirptext normalizes chirp text and measures it the way readers
+// count characters: an emoji, even one built from several code points,
+// or a letter with an accent counts once, whatever its size in bytes.
+package chirptext
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/LucaFe1337/Chipry/internal/entities"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// URLWeight is what every URL counts, however long it is, so links don't
+// eat into the limit.
+const URLWeight = 23
+
+var (
+	ErrInvalidUTF8      = errors.New("text must be valid UTF-8")
+	ErrControlCharacter = errors.New("text must not contain control characters")
+)
+
+// TooLongError reports text over the limit.
+type TooLongError struct {
+	Length int
+	Max    int
+}
+
+func (e *TooLongError) Error() string {
+	return fmt.Sprintf("Chirp is too long. Max length is %d characters.", e.Max)
+}
+
+// Remaining is negative: how many characters have to go.
+func (e *TooLongError) Remaining() int {
+	return e.Max - e.Length
+}
+
+// Normalize checks text and returns it in Unicode normalization form C,
+// so the same text is always stored the same way. Line breaks become \n;
+// tabs and line breaks are the only control characters allowed.
+func Normalize(text string) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", ErrInvalidUTF8
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return "", ErrControlCharacter
+		}
+	}
+	return norm.NFC.String(text), nil
+}
+
+// Length counts the grapheme clusters of text, with every URL counting
+// URLWeight.
+func Length(text string) int {
+	runes := []rune(text)
+	length, start := 0, 0
+	for _, url := range entities.Extract(text).URLs {
+		length += uniseg.GraphemeClusterCount(string(runes[start:url.Start])) + URLWeight
+		start = url.End
+	}
+	return length + uniseg.GraphemeClusterCount(string(runes[start:]))
+}
+
+// Validate normalizes text and checks that it is at most max characters
+// long. Text over the limit gets a *TooLongError.
+func Validate(text string, max int) (string, error) {
+	text, err := Normalize(text)
+	if err != nil {
+		return "", err
+	}
+	if length := Length(text); length > max {
+		return "", &TooLongError{Length: length, Max: max}
+	}
+	return text, nil
+}
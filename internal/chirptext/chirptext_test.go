@@ -0,0 +1,66 @@
+package chirptext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	// "é" as e and a combining acute accent becomes the single code point.
+	got, err := Normalize("Cafe\u0301\r\nok\tfine")
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if got != "Caf\u00e9\nok\tfine" {
+		t.Errorf("Normalize = %q", got)
+	}
+
+	if _, err := Normalize("bad \xff byte"); err != ErrInvalidUTF8 {
+		t.Errorf("invalid UTF-8: error = %v", err)
+	}
+	for _, text := range []string{"bell\a", "nul\x00", "lone\rreturn", "c1\u0085"} {
+		if _, err := Normalize(text); err != ErrControlCharacter {
+			t.Errorf("Normalize(%q): error = %v, want ErrControlCharacter", text, err)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	for text, want := range map[string]int{
+		"":                  0,
+		"hello":             5,
+		"Grüße aus München": 17,
+		// A family emoji is five code points joined into one.
+		"👨‍👩‍👧 ok": 4,
+		"🇩🇪🇫🇷":     2,
+		"e\u0301":  1,
+		"read https://example.com/a/very/long/path/that/goes/on/and/on please": 5 + URLWeight + 7,
+		"https://a.io": URLWeight,
+	} {
+		if got := Length(text); got != want {
+			t.Errorf("Length(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// 140 umlauts are 280 bytes but only 140 characters.
+	text := strings.Repeat("ü", 140)
+	if got, err := Validate(text, 140); err != nil || got != text {
+		t.Errorf("Validate(140 umlauts) = %q, %v", got, err)
+	}
+
+	_, err := Validate(strings.Repeat("🎉", 150), 140)
+	var tooLong *TooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("error = %v, want *TooLongError", err)
+	}
+	if tooLong.Length != 150 || tooLong.Max != 140 || tooLong.Remaining() != -10 {
+		t.Errorf("got %+v, remaining %d", tooLong, tooLong.Remaining())
+	}
+
+	if _, err := Validate("x\x1b[31m", 140); err != ErrControlCharacter {
+		t.Errorf("escape sequence: error = %v", err)
+	}
+}
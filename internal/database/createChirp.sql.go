@@ -12,21 +12,39 @@ import (
 )
 
 const createChirps = `-- name: CreateChirps :one
-INSERT INTO chirps(body, user_id)
+INSERT INTO chirps(id, body, user_id, in_reply_to, conversation_id, referenced_chirp_id, kind)
 VALUES(
 	$1,
-    $2
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, reply_count, deleted_at, referenced_chirp_id, kind, edited_at
 `
 
 type CreateChirpsParams struct {
-	Body   string
-	UserID uuid.UUID
+	ID                uuid.UUID
+	Body              string
+	UserID            uuid.NullUUID
+	InReplyTo         uuid.NullUUID
+	ConversationID    uuid.UUID
+	ReferencedChirpID uuid.NullUUID
+	Kind              string
 }
 
 func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirps, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirps,
+		arg.ID,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.ConversationID,
+		arg.ReferencedChirpID,
+		arg.Kind,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +52,13 @@ func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chi
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.ReferencedChirpID,
+		&i.Kind,
+		&i.EditedAt,
 	)
 	return i, err
 }
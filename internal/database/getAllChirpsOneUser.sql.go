@@ -12,10 +12,10 @@ import (
 )
 
 const allchirpsFromUser = `-- name: AllchirpsFromUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, reply_count, deleted_at, referenced_chirp_id, kind, edited_at FROM chirps WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at
 `
 
-func (q *Queries) AllchirpsFromUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+func (q *Queries) AllchirpsFromUser(ctx context.Context, userID uuid.NullUUID) ([]Chirp, error) {
 	rows, err := q.db.QueryContext(ctx, allchirpsFromUser, userID)
 	if err != nil {
 		return nil, err
@@ -30,6 +30,13 @@ func (q *Queries) AllchirpsFromUser(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.ReferencedChirpID,
+			&i.Kind,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -12,7 +12,7 @@ import (
 )
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, reply_count, deleted_at, referenced_chirp_id, kind, edited_at FROM chirps WHERE id = $1
 `
 
 func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -24,6 +24,13 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.ReferencedChirpID,
+		&i.Kind,
+		&i.EditedAt,
 	)
 	return i, err
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const getPasswordFromEmail = `-- name: GetPasswordFromEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, verified_at, totp_secret, totp_enabled_at, totp_last_step, role, delete_after, follower_count, following_count, handle, display_name, bio, location, website, avatar_key, header_key FROM users WHERE email = $1
 `
 
 func (q *Queries) GetPasswordFromEmail(ctx context.Context, email string) (User, error) {
@@ -23,6 +23,21 @@ func (q *Queries) GetPasswordFromEmail(ctx context.Context, email string) (User,
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.DeleteAfter,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
 	)
 	return i, err
 }
@@ -14,14 +14,17 @@ import (
 )
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT users.id, refresh_token.token, refresh_token.expires_at, refresh_token.revoked_at FROM refresh_token JOIN users ON users.id = refresh_token.user_id WHERE token = $1
+SELECT users.id, users.role, refresh_token.token, refresh_token.expires_at, refresh_token.revoked_at, refresh_token.family_id, refresh_token.replaced_by FROM refresh_token JOIN users ON users.id = refresh_token.user_id WHERE token = $1
 `
 
 type GetUserFromRefreshTokenRow struct {
-	ID        uuid.UUID
-	Token     string
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	ID         uuid.UUID
+	Role       string
+	Token      string
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	FamilyID   uuid.UUID
+	ReplacedBy sql.NullString
 }
 
 func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (GetUserFromRefreshTokenRow, error) {
@@ -29,9 +32,12 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Ge
 	var i GetUserFromRefreshTokenRow
 	err := row.Scan(
 		&i.ID,
+		&i.Role,
 		&i.Token,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
@@ -6,28 +6,249 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	Seq       int64
+	ID        uuid.UUID
+	CreatedAt time.Time
+	EventType string
+	ActorID   uuid.NullUUID
+	SubjectID uuid.NullUUID
+	Ip        string
+	Data      json.RawMessage
+	PrevHash  string
+	Hash      string
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Body              string
+	UserID            uuid.NullUUID
+	InReplyTo         uuid.NullUUID
+	ConversationID    uuid.UUID
+	ReplyCount        int32
+	DeletedAt         sql.NullTime
+	ReferencedChirpID uuid.NullUUID
+	Kind              string
+	EditedAt          sql.NullTime
+}
+
+type ChirpEntity struct {
+	ChirpID     uuid.UUID
+	Kind        string
+	StartOffset int32
+	EndOffset   int32
+	Value       string
+	UserID      uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	Tag       string
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpReaction struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Reaction  string
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type DataExport struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UserID       uuid.UUID
+	Status       string
+	Archive      []byte
+	CompletedAt  sql.NullTime
+	DownloadedAt sql.NullTime
+	ExpiresAt    time.Time
+}
+
+type EmailVerificationToken struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
 	UserID    uuid.UUID
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
 }
 
-type RefreshToken struct {
-	Token     string
+type EntityBackfill struct {
+	ChirpID uuid.UUID
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Handle struct {
+	Handle    string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type LoginAttempt struct {
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type MediaAttachment struct {
+	ID              uuid.UUID
+	UserID          uuid.NullUUID
+	ChirpID         uuid.NullUUID
+	Position        int32
+	Kind            string
+	Key             string
+	Width           int32
+	Height          int32
+	ThumbnailKey    string
+	ThumbnailWidth  int32
+	ThumbnailHeight int32
+	Blurhash        string
+	AltText         string
+	CreatedAt       time.Time
+	AttachedAt      sql.NullTime
+}
+
+type MfaRecoveryCode struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Word      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	CreatedAt     time.Time
+	ClientID      uuid.UUID
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+}
+
+type OauthClient struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris []string
+	Scopes       []string
+}
+
+type OauthRefreshToken struct {
+	TokenHash string
 	CreatedAt time.Time
-	UpdatedAt time.Time
+	ClientID  uuid.UUID
 	UserID    uuid.UUID
+	Scopes    []string
 	ExpiresAt time.Time
 	RevokedAt sql.NullTime
 }
 
+type OauthRevokedAccessToken struct {
+	Jti       uuid.UUID
+	ExpiresAt time.Time
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+type RefreshToken struct {
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	FamilyID   uuid.UUID
+	ReplacedBy sql.NullString
+	UserAgent  string
+	Ip         string
+	LastUsedAt time.Time
+}
+
+type Role struct {
+	Name string
+}
+
+type RolePermission struct {
+	Role       string
+	Permission string
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +256,28 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	VerifiedAt     sql.NullTime
+	TotpSecret     sql.NullString
+	TotpEnabledAt  sql.NullTime
+	TotpLastStep   int64
+	Role           string
+	DeleteAfter    sql.NullTime
+	FollowerCount  int32
+	FollowingCount int32
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	Location       string
+	Website        string
+	AvatarKey      sql.NullString
+	HeaderKey      sql.NullString
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
+	Email     string
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const allchirps = `-- name: Allchirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, reply_count, deleted_at, referenced_chirp_id, kind, edited_at FROM chirps WHERE deleted_at IS NULL ORDER BY created_at
 `
 
 func (q *Queries) Allchirps(ctx context.Context) ([]Chirp, error) {
@@ -28,6 +28,13 @@ func (q *Queries) Allchirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.ReferencedChirpID,
+			&i.Kind,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -18,7 +18,7 @@ VALUES(
 	$1,
 	$2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, verified_at, totp_secret, totp_enabled_at, totp_last_step, role, delete_after, follower_count, following_count, handle, display_name, bio, location, website, avatar_key, header_key
 `
 
 type CreateUserParams struct {
@@ -36,6 +36,21 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.Role,
+		&i.DeleteAfter,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
 	)
 	return i, err
 }
@@ -0,0 +1,192 @@
+// Package entities finds the hashtags, mentions and URLs in chirp text.
+// Offsets count Unicode code points, not bytes, from the start of the
+// text; End is exclusive. Clients slice the text at these offsets to turn
+// entities into links.
+package entities
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTagLength bounds hashtags, without the #. Longer ones aren't
+// recognised at all rather than cut short.
+const MaxTagLength = 100
+
+// maxHandleLength matches handles.MaxLength.
+const maxHandleLength = 15
+
+type Hashtag struct {
+	// Tag is the hashtag without the #, see NormalizeTag.
+	Tag        string
+	Start, End int
+}
+
+type Mention struct {
+	// Handle is written as in the text, without the @.
+	Handle     string
+	Start, End int
+}
+
+type URL struct {
+	// URL is the link as written, with http:// added to links starting
+	// with www.
+	URL        string
+	Start, End int
+}
+
+type Entities struct {
+	Hashtags []Hashtag
+	Mentions []Mention
+	URLs     []URL
+}
+
+// NormalizeTag returns the form hashtags are compared in, so #Go and #go
+// are the same tag, and so are tags whose accents are composed
+// differently. A leading # is dropped.
+func NormalizeTag(tag string) string {
+	return norm.NFC.String(strings.ToLower(strings.TrimPrefix(tag, "#")))
+}
+
+// ValidTag reports whether tag, without the #, would be recognised as a
+// hashtag.
+func ValidTag(tag string) bool {
+	runes := []rune(tag)
+	return len(runes) > 0 && tagLength(runes, 0) == len(runes)
+}
+
+// Extract returns the entities of text in the order they appear. Hashtags
+// and mentions inside URLs are part of the URL; mentions need a boundary
+// in front, so email addresses aren't mentions.
+func Extract(text string) Entities {
+	var e Entities
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !boundary(runes, i) {
+			i++
+			continue
+		}
+		if n := urlLength(runes, i); n > 0 {
+			url := string(runes[i : i+n])
+			if hasPrefixFold(url, "www.") {
+				url = "http://" + url
+			}
+			e.URLs = append(e.URLs, URL{URL: url, Start: i, End: i + n})
+			i += n
+			continue
+		}
+		switch runes[i] {
+		case '#':
+			if n := tagLength(runes, i+1); n > 0 {
+				e.Hashtags = append(e.Hashtags, Hashtag{Tag: NormalizeTag(string(runes[i+1 : i+1+n])), Start: i, End: i + 1 + n})
+				i += 1 + n
+				continue
+			}
+		case '@':
+			if n := handleLength(runes, i+1); n > 0 {
+				e.Mentions = append(e.Mentions, Mention{Handle: string(runes[i+1 : i+1+n]), Start: i, End: i + 1 + n})
+				i += 1 + n
+				continue
+			}
+		}
+		i++
+	}
+	return e
+}
+
+// boundary reports whether an entity may start at i: at the start of the
+// text or after something that can't be part of a word.
+func boundary(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := runes[i-1]
+	return !(wordRune(prev) || prev == '&' || prev == '#' || prev == '@' || prev == '/')
+}
+
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// tagLength returns the length of the hashtag starting at i, without the
+// #, or 0. Tags need a letter, so #1 is just a number.
+func tagLength(runes []rune, i int) int {
+	n, letter := 0, false
+	for i+n < len(runes) && wordRune(runes[i+n]) {
+		letter = letter || unicode.IsLetter(runes[i+n])
+		n++
+	}
+	if !letter || n > MaxTagLength {
+		return 0
+	}
+	return n
+}
+
+func handleRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
+
+// handleLength returns the length of the handle starting at i, or 0.
+// Longer runs can't be handles, and one followed by @ is part of an
+// address.
+func handleLength(runes []rune, i int) int {
+	n := 0
+	for i+n < len(runes) && handleRune(runes[i+n]) {
+		n++
+	}
+	if n == 0 || n > maxHandleLength {
+		return 0
+	}
+	if i+n < len(runes) && (runes[i+n] == '@' || wordRune(runes[i+n])) {
+		return 0
+	}
+	return n
+}
+
+// urlLength returns the length of the URL starting at i, or 0. URLs run
+// to the next space; punctuation at the end belongs to the sentence, and
+// so does a closing parenthesis without an opening one in the URL.
+func urlLength(runes []rune, i int) int {
+	rest := string(runes[i:min(len(runes), i+8)])
+	var prefix int
+	switch {
+	case hasPrefixFold(rest, "https://"):
+		prefix = 8
+	case hasPrefixFold(rest, "http://"):
+		prefix = 7
+	case hasPrefixFold(rest, "www."):
+		prefix = 4
+	default:
+		return 0
+	}
+	n := prefix
+	for i+n < len(runes) && !unicode.IsSpace(runes[i+n]) && !strings.ContainsRune(`<>"`, runes[i+n]) {
+		n++
+	}
+	for n > prefix {
+		last := runes[i+n-1]
+		if strings.ContainsRune(".,:;!?'*", last) {
+			n--
+			continue
+		}
+		if closer := strings.IndexRune(")]", last); closer >= 0 {
+			open := rune("(["[closer])
+			if strings.Count(string(runes[i:i+n]), string(open)) < strings.Count(string(runes[i:i+n]), string(last)) {
+				n--
+				continue
+			}
+		}
+		break
+	}
+	// The host needs at least one letter or digit.
+	if n == prefix || !unicode.IsLetter(runes[i+prefix]) && !unicode.IsDigit(runes[i+prefix]) {
+		return 0
+	}
+	return n
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
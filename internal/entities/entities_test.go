@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		text string
+		want Entities
+	}{
+		{
+			text: "Hello #Go and @alice!",
+			want: Entities{
+				Hashtags: []Hashtag{{Tag: "go", Start: 6, End: 9}},
+				Mentions: []Mention{{Handle: "alice", Start: 14, End: 20}},
+			},
+		},
+		{
+			// Offsets count code points, so the umlaut and emoji take one each.
+			text: "Grüße 🎉 #Über @bob_2",
+			want: Entities{
+				Hashtags: []Hashtag{{Tag: "über", Start: 8, End: 13}},
+				Mentions: []Mention{{Handle: "bob_2", Start: 14, End: 20}},
+			},
+		},
+		{
+			text: "see https://example.com/a#frag?x=@y, (www.example.org/wiki_(x)) and http://go.dev.",
+			want: Entities{
+				URLs: []URL{
+					{URL: "https://example.com/a#frag?x=@y", Start: 4, End: 35},
+					{URL: "http://www.example.org/wiki_(x)", Start: 38, End: 62},
+					{URL: "http://go.dev", Start: 68, End: 81},
+				},
+			},
+		},
+		{
+			// Addresses, numbers, anchors inside words and over-long
+			// handles aren't entities.
+			text: "mail me@example.com, #1 is C#, a&#39; @abcdefghijklmnop @a@b",
+			want: Entities{},
+		},
+		{
+			text: "#a#b @x.",
+			want: Entities{
+				Hashtags: []Hashtag{{Tag: "a", Start: 0, End: 2}},
+				Mentions: []Mention{{Handle: "x", Start: 5, End: 7}},
+			},
+		},
+		{
+			text: "https:// and www. alone",
+			want: Entities{},
+		},
+	}
+	for _, tc := range cases {
+		if got := Extract(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Extract(%q)\n got %+v\nwant %+v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestValidTag(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"go":      true,
+		"über":    true,
+		"go_1":    true,
+		"1":       false,
+		"":        false,
+		"go lang": false,
+		"#go":     false,
+	} {
+		if got := ValidTag(tag); got != want {
+			t.Errorf("ValidTag(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	for tag, want := range map[string]string{
+		"#Go":         "go",
+		"go":          "go",
+		"Cafe\u0301":  "caf\u00e9",
+		"CAF\u00c9":   "caf\u00e9",
+		"#\u00dcber":  "\u00fcber",
+		"#U\u0308ber": "\u00fcber",
+	} {
+		if got := NormalizeTag(tag); got != want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
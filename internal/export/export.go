@@ -0,0 +1,235 @@
+// Package export builds the archive a user downloads to take their data
+// with them: a ZIP file with one JSON document per kind of data.
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+// auditPageSize is how many audit events are read per query.
+const auditPageSize = 1000
+
+// Source is the part of database.Queries an export reads from.
+type Source interface {
+	GetUserById(ctx context.Context, id uuid.UUID) (database.User, error)
+	AllchirpsFromUser(ctx context.Context, userID uuid.NullUUID) ([]database.Chirp, error)
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]database.ListUserSessionsRow, error)
+	ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error)
+}
+
+type Profile struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Email       string     `json:"email"`
+	Handle      string     `json:"handle,omitempty"`
+	DisplayName string     `json:"display_name"`
+	Bio         string     `json:"bio"`
+	Location    string     `json:"location"`
+	Website     string     `json:"website"`
+	IsChirpyRed bool       `json:"is_chirpy_red"`
+	VerifiedAt  *time.Time `json:"verified_at"`
+	Role        string     `json:"role"`
+	TOTPEnabled bool       `json:"totp_enabled"`
+}
+
+type Chirp struct {
+	ID                uuid.UUID  `json:"id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Body              string     `json:"body"`
+	InReplyTo         *uuid.UUID `json:"in_reply_to"`
+	Kind              string     `json:"kind"`
+	ReferencedChirpID *uuid.UUID `json:"referenced_chirp_id"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+}
+
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `json:"type"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	SubjectID *uuid.UUID      `json:"subject_id"`
+	IP        string          `json:"ip"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Archive is everything exported for one user.
+type Archive struct {
+	CreatedAt   time.Time
+	Profile     Profile
+	Chirps      []Chirp
+	Sessions    []Session
+	AuditEvents []AuditEvent
+}
+
+func optionalUUID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+// Collect reads the data of userID. Audit events include those the user
+// caused and those that happened to their account.
+func Collect(ctx context.Context, db Source, userID uuid.UUID, now time.Time) (*Archive, error) {
+	user, err := db.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("reading profile: %w", err)
+	}
+	archive := &Archive{
+		CreatedAt: now.UTC(),
+		Profile: Profile{
+			ID:          user.ID,
+			CreatedAt:   user.CreatedAt,
+			UpdatedAt:   user.UpdatedAt,
+			Email:       user.Email,
+			Handle:      user.Handle.String,
+			DisplayName: user.DisplayName,
+			Bio:         user.Bio,
+			Location:    user.Location,
+			Website:     user.Website,
+			IsChirpyRed: user.IsChirpyRed,
+			Role:        user.Role,
+			TOTPEnabled: user.TotpEnabledAt.Valid,
+		},
+		Chirps:      []Chirp{},
+		Sessions:    []Session{},
+		AuditEvents: []AuditEvent{},
+	}
+	if user.VerifiedAt.Valid {
+		archive.Profile.VerifiedAt = &user.VerifiedAt.Time
+	}
+
+	chirps, err := db.AllchirpsFromUser(ctx, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("reading chirps: %w", err)
+	}
+	for _, chirp := range chirps {
+		archive.Chirps = append(archive.Chirps, Chirp{
+			ID:                chirp.ID,
+			CreatedAt:         chirp.CreatedAt,
+			UpdatedAt:         chirp.UpdatedAt,
+			Body:              chirp.Body,
+			InReplyTo:         optionalUUID(chirp.InReplyTo),
+			Kind:              chirp.Kind,
+			ReferencedChirpID: optionalUUID(chirp.ReferencedChirpID),
+		})
+	}
+
+	sessions, err := db.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("reading sessions: %w", err)
+	}
+	for _, session := range sessions {
+		archive.Sessions = append(archive.Sessions, Session{
+			ID:         session.FamilyID,
+			CreatedAt:  session.StartedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			IP:         session.Ip,
+		})
+	}
+
+	params := database.ListAuditEventsParams{
+		UserID:    uuid.NullUUID{UUID: userID, Valid: true},
+		BeforeSeq: 1<<63 - 1,
+		MaxEvents: auditPageSize,
+	}
+	for {
+		events, err := db.ListAuditEvents(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("reading audit events: %w", err)
+		}
+		for _, event := range events {
+			exported := AuditEvent{
+				ID:        event.ID,
+				CreatedAt: event.CreatedAt,
+				Type:      event.EventType,
+				ActorID:   optionalUUID(event.ActorID),
+				SubjectID: optionalUUID(event.SubjectID),
+				IP:        event.Ip,
+				Data:      event.Data,
+			}
+			// Staff acting on the account are not the user's data: who
+			// they are and where they were stays out of the archive.
+			if event.ActorID.Valid && event.ActorID.UUID != userID {
+				exported.ActorID = nil
+				exported.IP = ""
+			}
+			archive.AuditEvents = append(archive.AuditEvents, exported)
+		}
+		if len(events) < auditPageSize {
+			break
+		}
+		params.BeforeSeq = events[len(events)-1].Seq
+	}
+	return archive, nil
+}
+
+const readme = `This archive contains the data Chirpy stores about your account.
+
+profile.json       your account details
+chirps.json        every chirp you posted
+sessions.json      devices that are currently signed in
+audit_events.json  security events of your account, newest first
+
+Passwords, tokens and two-factor secrets are never exported.
+`
+
+// WriteZip writes the archive as a ZIP file.
+func (a *Archive) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", a.Profile},
+		{"chirps.json", a.Chirps},
+		{"sessions.json", a.Sessions},
+		{"audit_events.json", a.AuditEvents},
+	}
+	if err := writeFile(zw, "README.txt", a.CreatedAt, []byte(readme)); err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", file.name, err)
+		}
+		if err := writeFile(zw, file.name, a.CreatedAt, data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeFile(zw *zip.Writer, name string, modified time.Time, data []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
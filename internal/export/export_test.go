@@ -0,0 +1,166 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+type fakeSource struct {
+	user   database.User
+	chirps []database.Chirp
+	events []database.AuditEvent
+}
+
+func (f *fakeSource) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return f.user, nil
+}
+
+func (f *fakeSource) AllchirpsFromUser(ctx context.Context, userID uuid.NullUUID) ([]database.Chirp, error) {
+	return f.chirps, nil
+}
+
+func (f *fakeSource) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]database.ListUserSessionsRow, error) {
+	return nil, nil
+}
+
+// ListAuditEvents pages through events, which are stored newest first.
+func (f *fakeSource) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	page := []database.AuditEvent{}
+	for _, event := range f.events {
+		if event.Seq < arg.BeforeSeq && len(page) < int(arg.MaxEvents) {
+			page = append(page, event)
+		}
+	}
+	return page, nil
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll %s failed: %v", f.Name, err)
+		}
+		files[f.Name] = content
+	}
+	return files
+}
+
+func TestCollect_WriteZip(t *testing.T) {
+	userID := uuid.New()
+	source := &fakeSource{
+		user: database.User{
+			ID:             userID,
+			Email:          "user@example.com",
+			HashedPassword: "$argon2id$secret",
+			Role:           "user",
+		},
+		chirps: []database.Chirp{{ID: uuid.New(), Body: "Hallo Welt", UserID: uuid.NullUUID{UUID: userID, Valid: true}}},
+	}
+	for seq := int64(2500); seq > 0; seq-- {
+		source.events = append(source.events, database.AuditEvent{
+			Seq:       seq,
+			ID:        uuid.New(),
+			EventType: "login.success",
+			SubjectID: uuid.NullUUID{UUID: userID, Valid: true},
+			Data:      json.RawMessage(`{"method":"password"}`),
+		})
+	}
+
+	archive, err := Collect(context.Background(), source, userID, time.Now())
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(archive.AuditEvents) != 2500 {
+		t.Errorf("Expected all 2500 audit events across pages, got %d", len(archive.AuditEvents))
+	}
+
+	var buf bytes.Buffer
+	if err := archive.WriteZip(&buf); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+	for _, name := range []string{"README.txt", "profile.json", "chirps.json", "sessions.json", "audit_events.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in archive", name)
+		}
+	}
+	if bytes.Contains(files["profile.json"], []byte("argon2id")) {
+		t.Error("Password hash must never be exported")
+	}
+
+	var chirps []Chirp
+	if err := json.Unmarshal(files["chirps.json"], &chirps); err != nil {
+		t.Fatalf("Unmarshal chirps failed: %v", err)
+	}
+	if len(chirps) != 1 || chirps[0].Body != "Hallo Welt" {
+		t.Errorf("Unexpected chirps %+v", chirps)
+	}
+	var sessions []Session
+	if err := json.Unmarshal(files["sessions.json"], &sessions); err != nil || sessions == nil {
+		t.Errorf("Expected an empty sessions list, got %s", files["sessions.json"])
+	}
+}
+
+func TestCollect_HidesStaffActors(t *testing.T) {
+	userID, staffID := uuid.New(), uuid.New()
+	source := &fakeSource{
+		user: database.User{ID: userID, Email: "user@example.com", Role: "user"},
+		events: []database.AuditEvent{
+			{
+				Seq:       2,
+				ID:        uuid.New(),
+				EventType: "user.role_change",
+				ActorID:   uuid.NullUUID{UUID: staffID, Valid: true},
+				SubjectID: uuid.NullUUID{UUID: userID, Valid: true},
+				Ip:        "203.0.113.7",
+				Data:      json.RawMessage(`{"role":"moderator"}`),
+			},
+			{
+				Seq:       1,
+				ID:        uuid.New(),
+				EventType: "login.success",
+				ActorID:   uuid.NullUUID{UUID: userID, Valid: true},
+				SubjectID: uuid.NullUUID{UUID: userID, Valid: true},
+				Ip:        "198.51.100.1",
+				Data:      json.RawMessage(`{"method":"password"}`),
+			},
+		},
+	}
+
+	archive, err := Collect(context.Background(), source, userID, time.Now())
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(archive.AuditEvents) != 2 {
+		t.Fatalf("Expected 2 audit events, got %d", len(archive.AuditEvents))
+	}
+	staff, own := archive.AuditEvents[0], archive.AuditEvents[1]
+	if staff.ActorID != nil || staff.IP != "" {
+		t.Errorf("Expected the staff actor and IP to be left out, got %v and %q", staff.ActorID, staff.IP)
+	}
+	if staff.Type != "user.role_change" || staff.SubjectID == nil || *staff.SubjectID != userID {
+		t.Errorf("Expected the staff event itself to be exported, got %+v", staff)
+	}
+	if own.ActorID == nil || *own.ActorID != userID || own.IP != "198.51.100.1" {
+		t.Errorf("Expected the user's own actor and IP to be exported, got %+v", own)
+	}
+}
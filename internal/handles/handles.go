@@ -0,0 +1,81 @@
+// Package handles validates the names users are found by, like @chirpy.
+// Handles keep the case they were chosen in but are unique and looked up
+// regardless of case.
+package handles
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 15
+)
+
+var (
+	ErrInvalid  = errors.New("handles are 3 to 15 letters, digits or underscores")
+	ErrReserved = errors.New("this handle is reserved")
+)
+
+// reserved are handles nobody can take: names that could pass for the
+// service or its staff, and words used in URLs.
+var reserved = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"app":           true,
+	"auth":          true,
+	"blocks":        true,
+	"chirp":         true,
+	"chirps":        true,
+	"chirpy":        true,
+	"explore":       true,
+	"export":        true,
+	"exports":       true,
+	"help":          true,
+	"home":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"mutes":         true,
+	"null":          true,
+	"oauth":         true,
+	"official":      true,
+	"profile":       true,
+	"root":          true,
+	"search":        true,
+	"security":      true,
+	"settings":      true,
+	"signup":        true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"timeline":      true,
+	"undefined":     true,
+	"users":         true,
+}
+
+// Normalize returns the form handles are compared in. A leading @ is
+// dropped.
+func Normalize(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// Validate checks that handle, without a leading @, may be taken.
+func Validate(handle string) error {
+	if len(handle) < MinLength || len(handle) > MaxLength {
+		return ErrInvalid
+	}
+	for _, r := range handle {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return ErrInvalid
+		}
+	}
+	normalized := Normalize(handle)
+	if reserved[normalized] || reserved[strings.Trim(normalized, "_")] || strings.HasPrefix(normalized, "chirpy") {
+		return ErrReserved
+	}
+	return nil
+}
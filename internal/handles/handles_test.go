@@ -0,0 +1,40 @@
+package handles
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	cases := map[string]error{
+		"luca_fe":          nil,
+		"Luca1337":         nil,
+		"abc":              nil,
+		"fifteen_chars_x":  nil,
+		"ab":               ErrInvalid,
+		"sixteen_chars_xx": ErrInvalid,
+		"has space":        ErrInvalid,
+		"dash-ed":          ErrInvalid,
+		"@luca":            ErrInvalid,
+		"lücä":             ErrInvalid,
+		"Admin":            ErrReserved,
+		"_admin_":          ErrReserved,
+		"ChirpySupport":    ErrReserved,
+		"me_":              ErrReserved,
+	}
+	for handle, want := range cases {
+		if got := Validate(handle); got != want {
+			t.Errorf("Validate(%q) = %v, want %v", handle, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Luca_Fe": "luca_fe",
+		"@Luca":   "luca",
+		"luca":    "luca",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
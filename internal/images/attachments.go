@@ -0,0 +1,146 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image/gif"
+)
+
+const (
+	// MaxAttachmentSize bounds the longer side of chirp images; larger
+	// ones are scaled down.
+	MaxAttachmentSize = 2048
+	// ThumbnailSize bounds the longer side of thumbnails.
+	ThumbnailSize = 400
+	// MaxAnimationPixels bounds the pixels of all frames of a GIF
+	// together, since they are all decoded at once, at a byte per pixel.
+	MaxAnimationPixels = 40_000_000
+)
+
+var errMalformedGIF = errors.New("malformed GIF")
+
+// Attachment is an image attached to a chirp, with a thumbnail and a
+// blurhash placeholder to show while it loads.
+type Attachment struct {
+	Image     Result
+	Thumbnail Result
+	Blurhash  string
+	// Animated is set for GIFs with more than one frame. They keep their
+	// size and every frame; anything else is scaled down to fit
+	// MaxAttachmentSize.
+	Animated bool
+}
+
+// ProcessAttachment checks and re-encodes an uploaded chirp image, which
+// drops its metadata, and makes the thumbnail. Thumbnails of animations
+// show the first frame.
+func ProcessAttachment(data []byte) (Attachment, error) {
+	defer acquireDecode()()
+	src, contentType, err := decode(data)
+	if err != nil {
+		return Attachment{}, err
+	}
+	var att Attachment
+	if contentType == "image/gif" {
+		frames, err := gifFrames(data)
+		if err != nil {
+			return Attachment{}, ErrUnsupported
+		}
+		if frames > 1 {
+			att.Image, err = encodeAnimation(data, frames)
+			if err != nil {
+				return Attachment{}, err
+			}
+			att.Animated = true
+		}
+	}
+	if !att.Animated {
+		att.Image, err = encode(fit(src, MaxAttachmentSize), contentType)
+		if err != nil {
+			return Attachment{}, err
+		}
+	}
+	thumbnail := fit(src, ThumbnailSize)
+	att.Thumbnail, err = encode(thumbnail, contentType)
+	if err != nil {
+		return Attachment{}, err
+	}
+	att.Blurhash = Blurhash(fit(thumbnail, 32), 4, 3)
+	return att, nil
+}
+
+// encodeAnimation decodes every frame of a GIF and encodes them again,
+// which keeps the timing and loop count but drops comments and
+// application data.
+func encodeAnimation(data []byte, frames int) (Result, error) {
+	config, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+	if frames*config.Width*config.Height > MaxAnimationPixels {
+		return Result{}, ErrTooLarge
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupported
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Data:        buf.Bytes(),
+		ContentType: "image/gif",
+		Ext:         ".gif",
+		Width:       config.Width,
+		Height:      config.Height,
+	}, nil
+}
+
+// gifFrames counts the frames of a GIF without decoding them.
+func gifFrames(data []byte) (int, error) {
+	if len(data) < 13 {
+		return 0, errMalformedGIF
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return 0, errMalformedGIF
+			}
+			i += 2
+		case 0x2C: // image descriptor
+			if i+10 > len(data) {
+				return 0, errMalformedGIF
+			}
+			if flags := data[i+9]; flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			// Skip the minimum LZW code size.
+			i += 11
+			frames++
+		case 0x3B: // trailer
+			return frames, nil
+		default:
+			return 0, errMalformedGIF
+		}
+		// Data sub-blocks, ended by an empty one.
+		for {
+			if i >= len(data) {
+				return 0, errMalformedGIF
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+	// Many encoders leave out the trailer.
+	return frames, nil
+}
@@ -0,0 +1,151 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestProcessAttachment_ScalesDownAndMakesThumbnail(t *testing.T) {
+	upload := withEXIF(t, encodeJPEG(t, halves(3000, 1000)), 1)
+	att, err := ProcessAttachment(upload)
+	if err != nil {
+		t.Fatalf("ProcessAttachment: %v", err)
+	}
+	if att.Animated {
+		t.Error("JPEG marked as animated")
+	}
+	if att.Image.Width != 2048 || att.Image.Height != 682 {
+		t.Errorf("image size = %dx%d, want 2048x682", att.Image.Width, att.Image.Height)
+	}
+	if att.Thumbnail.Width != 400 || att.Thumbnail.Height != 133 {
+		t.Errorf("thumbnail size = %dx%d, want 400x133", att.Thumbnail.Width, att.Thumbnail.Height)
+	}
+	if bytes.Contains(att.Image.Data, []byte("GPS")) {
+		t.Error("image still carries EXIF data")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(att.Thumbnail.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 133 {
+		t.Errorf("encoded thumbnail is %v", b)
+	}
+	if len(att.Blurhash) != 28 {
+		t.Errorf("blurhash %q has length %d, want 28", att.Blurhash, len(att.Blurhash))
+	}
+}
+
+func TestProcessAttachment_KeepsSmallImages(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, halves(100, 50))
+	att, err := ProcessAttachment(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ProcessAttachment: %v", err)
+	}
+	if att.Image.Width != 100 || att.Image.Height != 50 || att.Image.ContentType != "image/png" {
+		t.Errorf("image = %dx%d %s, want 100x50 image/png", att.Image.Width, att.Image.Height, att.Image.ContentType)
+	}
+}
+
+func animation(frames int) *gif.GIF {
+	anim := &gif.GIF{LoopCount: 0}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 20, 10), palette.Plan9)
+		for x := 0; x < 20; x++ {
+			frame.Set(x, i%10, color.White)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	return anim
+}
+
+func TestProcessAttachment_KeepsAnimation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation(3)); err != nil {
+		t.Fatal(err)
+	}
+	// A comment extension right before the trailer.
+	data := append(bytes.TrimSuffix(buf.Bytes(), []byte{0x3B}), 0x21, 0xFE, 6)
+	data = append(data, "secret"...)
+	data = append(data, 0, 0x3B)
+
+	att, err := ProcessAttachment(data)
+	if err != nil {
+		t.Fatalf("ProcessAttachment: %v", err)
+	}
+	if !att.Animated || att.Image.ContentType != "image/gif" {
+		t.Fatalf("animated = %v, content type %q", att.Animated, att.Image.ContentType)
+	}
+	if bytes.Contains(att.Image.Data, []byte("secret")) {
+		t.Error("comment survived")
+	}
+	out, err := gif.DecodeAll(bytes.NewReader(att.Image.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Image) != 3 || att.Image.Width != 20 || att.Image.Height != 10 {
+		t.Errorf("got %d frames of %dx%d, want 3 of 20x10", len(out.Image), att.Image.Width, att.Image.Height)
+	}
+	if att.Thumbnail.ContentType != "image/png" {
+		t.Errorf("thumbnail content type = %q, want image/png", att.Thumbnail.ContentType)
+	}
+}
+
+func TestGIFFrames(t *testing.T) {
+	for _, n := range []int{1, 2, 7} {
+		var buf bytes.Buffer
+		gif.EncodeAll(&buf, animation(n))
+		got, err := gifFrames(buf.Bytes())
+		if err != nil || got != n {
+			t.Errorf("gifFrames = %d, %v; want %d", got, err, n)
+		}
+		if _, err := gifFrames(buf.Bytes()[:buf.Len()/2]); err == nil {
+			t.Errorf("%d frames: truncated GIF accepted", n)
+		}
+	}
+}
+
+func solid(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestBlurhash(t *testing.T) {
+	// The first character encodes 4x3 components, the four after the
+	// maximum AC value the average colour.
+	for name, tc := range map[string]struct {
+		img  *image.RGBA
+		want string
+	}{
+		"white": {solid(color.RGBA{255, 255, 255, 255}), "TSUA"},
+		"black": {solid(color.RGBA{0, 0, 0, 255}), "0000"},
+	} {
+		hash := Blurhash(tc.img, 4, 3)
+		if len(hash) != 28 || hash[0] != 'L' || hash[2:6] != tc.want {
+			t.Errorf("%s: hash = %q, want L?%s...", name, hash, tc.want)
+		}
+	}
+	if hash := Blurhash(solid(color.RGBA{0, 0, 0, 255}), 1, 1); hash != "000000" {
+		t.Errorf("1x1 black = %q, want 000000", hash)
+	}
+
+	// Red left and blue right: the first horizontal component raises red
+	// on the left and blue on the right.
+	hash := Blurhash(halves(32, 32), 4, 3)
+	i := strings.IndexByte(base83, hash[6])*83 + strings.IndexByte(base83, hash[7])
+	r, b := i/(19*19), i%19
+	if r <= 9 || b >= 9 {
+		t.Errorf("first AC component of %q: red %d, blue %d", hash, r, b)
+	}
+}
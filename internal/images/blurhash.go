@@ -0,0 +1,100 @@
+package images
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// srgbToLinear maps 8-bit sRGB values to linear light.
+var srgbToLinear [256]float64
+
+func init() {
+	for i := range srgbToLinear {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			srgbToLinear[i] = v / 12.92
+		} else {
+			srgbToLinear[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+}
+
+// Blurhash encodes img as a BlurHash (https://blurha.sh) with the given
+// number of components, each between 1 and 9. Images are small enough to
+// encode quickly once scaled to around 32 pixels.
+func Blurhash(img *image.RGBA, xComponents, yComponents int) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var f [3]float64
+			for y := 0; y < h; y++ {
+				cy := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+				p := img.PixOffset(b.Min.X, b.Min.Y+y)
+				for x := 0; x < w; x++ {
+					basis := normalisation * math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * cy
+					f[0] += basis * srgbToLinear[img.Pix[p]]
+					f[1] += basis * srgbToLinear[img.Pix[p+1]]
+					f[2] += basis * srgbToLinear[img.Pix[p+2]]
+					p += 4
+				}
+			}
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	writeBase83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+	ac := factors[1:]
+	maximum := 1.0
+	if len(ac) > 0 {
+		actual := 0.0
+		for _, f := range ac {
+			actual = max(actual, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantised := int(max(0, min(82, math.Floor(actual*166-0.5))))
+		maximum = float64(quantised+1) / 166
+		writeBase83(&hash, quantised, 1)
+	} else {
+		writeBase83(&hash, 0, 1)
+	}
+	dc := factors[0]
+	writeBase83(&hash, linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		writeBase83(&hash, quantiseAC(f[0], maximum)*19*19+quantiseAC(f[1], maximum)*19+quantiseAC(f[2], maximum), 2)
+	}
+	return hash.String()
+}
+
+func linearToSRGB(v float64) int {
+	v = max(0, min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func quantiseAC(v, maximum float64) int {
+	v /= maximum
+	signed := math.Copysign(math.Sqrt(math.Abs(v)), v)
+	return int(max(0, min(18, math.Floor(signed*9+9.5))))
+}
+
+func writeBase83(b *strings.Builder, value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		digit := value
+		for n := 0; n < i; n++ {
+			digit /= 83
+		}
+		b.WriteByte(base83[digit%83])
+	}
+}
@@ -0,0 +1,293 @@
+// Package images turns uploaded pictures into the fixed-size images shown
+// on profiles and the images attached to chirps. Uploads are decoded and
+// encoded again, which drops EXIF and every other kind of metadata; the
+// EXIF orientation is applied to the pixels first so photos stay upright.
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels bounds the size of decoded uploads, so a small file can't
+// claim dimensions that need gigabytes of memory. While one is decoded,
+// the decoded image and its RGBA copy are in memory together, up to
+// about 200 MB at the limit.
+const MaxPixels = 24_000_000
+
+// maxConcurrentDecodes bounds how many uploads are processed at once, and
+// with MaxPixels the memory they need together.
+const maxConcurrentDecodes = 4
+
+var decodeSlots = make(chan struct{}, maxConcurrentDecodes)
+
+// acquireDecode waits for a free decode slot; call the returned function
+// to give it back.
+func acquireDecode() func() {
+	decodeSlots <- struct{}{}
+	return func() { <-decodeSlots }
+}
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupported = errors.New("images must be JPEG, PNG or GIF")
+	ErrTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Spec is the size images are cropped and scaled to.
+type Spec struct {
+	Width, Height int
+}
+
+var (
+	Avatar = Spec{Width: 400, Height: 400}
+	Header = Spec{Width: 1500, Height: 500}
+)
+
+// Result is a processed image.
+type Result struct {
+	Data        []byte
+	ContentType string
+	// Ext is the file extension for ContentType, with the dot.
+	Ext           string
+	Width, Height int
+}
+
+// Process decodes data, crops it to the aspect ratio of spec around the
+// centre and scales it to spec. JPEGs stay JPEGs; PNGs and GIFs become
+// PNGs so transparency survives. Only the first frame of a GIF is kept.
+func Process(data []byte, spec Spec) (Result, error) {
+	defer acquireDecode()()
+	src, contentType, err := decode(data)
+	if err != nil {
+		return Result{}, err
+	}
+	return encode(resize(cropToAspect(src, spec), spec.Width, spec.Height), contentType)
+}
+
+// decode checks the real type of data and decodes it upright. It returns
+// the sniffed content type.
+func decode(data []byte) (*image.RGBA, string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, "", ErrUnsupported
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = exifOrientation(data)
+	}
+	return orient(src, orientation), contentType, nil
+}
+
+// encode writes img as JPEG if it came from one and as PNG otherwise.
+func encode(img *image.RGBA, contentType string) (Result, error) {
+	var buf bytes.Buffer
+	result := Result{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Result{}, err
+		}
+		result.Data, result.ContentType, result.Ext = buf.Bytes(), "image/jpeg", ".jpg"
+		return result, nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return Result{}, err
+	}
+	result.Data, result.ContentType, result.Ext = buf.Bytes(), "image/png", ".png"
+	return result, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// cropToAspect returns the largest centred part of img with the aspect
+// ratio of spec.
+func cropToAspect(img *image.RGBA, spec Spec) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	cw, ch := w, h
+	if w*spec.Height > h*spec.Width {
+		cw = h * spec.Width / spec.Height
+	} else {
+		ch = w * spec.Height / spec.Width
+	}
+	cw, ch = max(cw, 1), max(ch, 1)
+	x0, y0 := (w-cw)/2, (h-ch)/2
+	return img.SubImage(image.Rect(x0, y0, x0+cw, y0+ch)).(*image.RGBA)
+}
+
+// fit scales img down so that neither side exceeds size, keeping its
+// aspect ratio. Smaller images are returned as they are.
+func fit(img *image.RGBA, size int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	if w >= h {
+		return resize(img, size, max(h*size/w, 1))
+	}
+	return resize(img, max(w*size/h, 1), size)
+}
+
+// resize scales img to width x height. Every output pixel is the average
+// of the source pixels it covers, which keeps downscaled photos smooth.
+func resize(img *image.RGBA, width, height int) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy0 := y * sh / height
+		sy1 := max((y+1)*sh/height, sy0+1)
+		for x := 0; x < width; x++ {
+			sx0 := x * sw / width
+			sx1 := max((x+1)*sw/width, sx0+1)
+			var r, g, bl, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				i := img.PixOffset(b.Min.X+sx0, b.Min.Y+sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(img.Pix[i])
+					g += uint32(img.Pix[i+1])
+					bl += uint32(img.Pix[i+2])
+					a += uint32(img.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// orient converts img to RGBA and transforms it as EXIF orientation o
+// prescribes for display. It goes a row at a time, so the only full-size
+// copy is the result.
+func orient(img image.Image, o int) *image.RGBA {
+	if o < 2 || o > 8 {
+		return toRGBA(img)
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	row := image.NewRGBA(image.Rect(0, 0, w, 1))
+	for sy := 0; sy < h; sy++ {
+		draw.Draw(row, row.Bounds(), img, image.Pt(b.Min.X, b.Min.Y+sy), draw.Src)
+		for sx := 0; sx < w; sx++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirrored
+				dx, dy = w-1-sx, sy
+			case 3: // upside down
+				dx, dy = w-1-sx, h-1-sy
+			case 4: // mirrored upside down
+				dx, dy = sx, h-1-sy
+			case 5: // transposed
+				dx, dy = sy, sx
+			case 6: // rotate clockwise
+				dx, dy = h-1-sy, sx
+			case 7: // transversed
+				dx, dy = h-1-sy, w-1-sx
+			case 8: // rotate counterclockwise
+				dx, dy = sy, w-1-sx
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], row.Pix[sx*4:][:4])
+		}
+	}
+	return dst
+}
+
+// exifOrientation returns the orientation tag of a JPEG, or 1 if it has
+// none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			// Image data starts; metadata comes before it.
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,171 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a w x h image whose left half is red and right half blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// withEXIF inserts an APP1 segment with the orientation tag and a GPS
+// position stand-in right after the SOI marker of a JPEG.
+func withEXIF(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 52.5200N 13.4050E")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestProcess_StripsEXIFAndAppliesOrientation(t *testing.T) {
+	upload := withEXIF(t, encodeJPEG(t, halves(64, 32)), 6)
+	if exifOrientation(upload) != 6 {
+		t.Fatal("test image has no orientation tag")
+	}
+
+	result, err := Process(upload, Spec{Width: 16, Height: 32})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.ContentType != "image/jpeg" || result.Ext != ".jpg" {
+		t.Errorf("content type = %q, %q", result.ContentType, result.Ext)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+		t.Error("processed image still carries EXIF data")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Fatalf("size = %v, want 16x32", b)
+	}
+	// Rotating clockwise puts the red left half on top.
+	if !isRed(img.At(8, 4)) || !isBlue(img.At(8, 28)) {
+		t.Errorf("orientation not applied: top %v, bottom %v", img.At(8, 4), img.At(8, 28))
+	}
+}
+
+func TestProcess_CropsToAspectAroundCentre(t *testing.T) {
+	// A wide image cropped to a square keeps the middle, where red and
+	// blue meet.
+	var buf bytes.Buffer
+	png.Encode(&buf, halves(300, 100))
+	result, err := Process(buf.Bytes(), Spec{Width: 10, Height: 10})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.ContentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", result.ContentType)
+	}
+	img, err := png.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isRed(img.At(1, 5)) || !isBlue(img.At(8, 5)) {
+		t.Errorf("crop is not centred: left %v, right %v", img.At(1, 5), img.At(8, 5))
+	}
+}
+
+func TestProcess_RejectsOtherContent(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"text":      []byte("hello, this is not an image"),
+		"svg":       []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+		"truncated": encodeJPEG(t, halves(8, 8))[:20],
+	} {
+		if _, err := Process(data, Avatar); err != ErrUnsupported {
+			t.Errorf("%s: error = %v, want ErrUnsupported", name, err)
+		}
+	}
+}
+
+func TestProcess_RejectsHugeDimensions(t *testing.T) {
+	// A PNG header claiming 100000 x 100000 pixels.
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := Process(data, Avatar); err != ErrTooLarge {
+		t.Errorf("error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1: red, blue.
+	src := halves(2, 1)
+	cases := map[int][2]image.Point{
+		// orientation: where red and blue end up
+		1: {{0, 0}, {1, 0}},
+		2: {{1, 0}, {0, 0}},
+		3: {{1, 0}, {0, 0}},
+		4: {{0, 0}, {1, 0}},
+		5: {{0, 0}, {0, 1}},
+		6: {{0, 0}, {0, 1}},
+		7: {{0, 1}, {0, 0}},
+		8: {{0, 1}, {0, 0}},
+	}
+	for o, want := range cases {
+		out := orient(src, o)
+		if out.RGBAAt(want[0].X, want[0].Y) != red || out.RGBAAt(want[1].X, want[1].Y) != blue {
+			t.Errorf("orientation %d: red/blue not at %v", o, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Brute-force protection for logins: failed attempts are counted per
+// account and per client IP, and both are locked out with exponential
+// backoff once they cross a threshold.
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Entry is the state kept for one account or IP.
+type Entry struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store persists entries. MemoryStore suits a single instance; with
+// several replicas use PostgresStore so they share one view.
+type Store interface {
+	// Get returns the entry for key, or the zero Entry if there is none.
+	Get(ctx context.Context, key string) (Entry, error)
+	// RecordFailure adds a failure and returns the new count. Failures
+	// before windowStart are forgotten, so the count restarts at one.
+	RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy decides when and for how long a key is locked.
+type Policy struct {
+	// Threshold is the number of failures that triggers the first lockout.
+	Threshold int
+	// BaseDelay is the first lockout; each further failure doubles it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered.
+	Window time.Duration
+}
+
+var (
+	DefaultAccountPolicy = Policy{Threshold: 5, BaseDelay: 30 * time.Second, MaxDelay: 15 * time.Minute, Window: time.Hour}
+	DefaultIPPolicy      = Policy{Threshold: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+)
+
+// Delay returns how long to lock a key after its failures-th failure.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return delay
+}
+
+type Limiter struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+	// Now is the clock, replaceable in tests.
+	Now func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		Store:   store,
+		Account: DefaultAccountPolicy,
+		IP:      DefaultIPPolicy,
+		Now:     time.Now,
+	}
+}
+
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the account or the IP is still locked, or zero
+// if a login may be attempted.
+func (l *Limiter) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := l.Now()
+	var wait time.Duration
+	for _, key := range []string{AccountKey(email), IPKey(ip)} {
+		entry, err := l.Store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if remaining := entry.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// Fail records a failed login and returns the lockout it caused, if any.
+func (l *Limiter) Fail(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := l.Now()
+	var wait time.Duration
+	for key, policy := range map[string]Policy{AccountKey(email): l.Account, IPKey(ip): l.IP} {
+		failures, err := l.Store.RecordFailure(ctx, key, now, now.Add(-policy.Window))
+		if err != nil {
+			return 0, err
+		}
+		delay := policy.Delay(failures)
+		if delay == 0 {
+			continue
+		}
+		if err := l.Store.Lock(ctx, key, now.Add(delay)); err != nil {
+			return 0, err
+		}
+		if delay > wait {
+			wait = delay
+		}
+	}
+	return wait, nil
+}
+
+// Succeed clears the failures of an account after a successful login.
+// The IP counter is left alone, otherwise an attacker holding one valid
+// account could reset it between guesses at others.
+func (l *Limiter) Succeed(ctx context.Context, email string) error {
+	return l.Store.Reset(ctx, AccountKey(email))
+}
+
+// Unlock lifts the lockout of an account.
+func (l *Limiter) Unlock(ctx context.Context, email string) error {
+	return l.Store.Reset(ctx, AccountKey(email))
+}
@@ -0,0 +1,125 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter() (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(NewMemoryStore())
+	limiter.Account = Policy{Threshold: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute, Window: time.Hour}
+	limiter.IP = Policy{Threshold: 10, BaseDelay: 10 * time.Second, MaxDelay: time.Minute, Window: time.Hour}
+	limiter.Now = clock.Now
+	return limiter, clock
+}
+
+func TestPolicy_DelayDoublesUpToMax(t *testing.T) {
+	policy := Policy{Threshold: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+	cases := map[int]time.Duration{
+		1: 0,
+		2: 0,
+		3: 10 * time.Second,
+		4: 20 * time.Second,
+		5: 40 * time.Second,
+		6: time.Minute,
+		9: time.Minute,
+	}
+	for failures, want := range cases {
+		if got := policy.Delay(failures); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestLimiter_LocksAccountAfterThreshold(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestLimiter()
+
+	for i := 0; i < 2; i++ {
+		wait, err := limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("Fail failed: %v", err)
+		}
+		if wait != 0 {
+			t.Fatalf("Expected no lockout after %d failures, got %v", i+1, wait)
+		}
+	}
+	wait, _ := limiter.Fail(ctx, "User@Example.com", "10.0.0.2")
+	if wait != 10*time.Second {
+		t.Fatalf("Expected 10s lockout, got %v", wait)
+	}
+
+	wait, _ = limiter.Check(ctx, "user@example.com", "10.0.0.3")
+	if wait != 10*time.Second {
+		t.Fatalf("Expected account to be locked from any IP, got %v", wait)
+	}
+	if wait, _ := limiter.Check(ctx, "other@example.com", "10.0.0.3"); wait != 0 {
+		t.Fatalf("Expected other accounts to be unaffected, got %v", wait)
+	}
+
+	clock.now = clock.now.Add(11 * time.Second)
+	if wait, _ := limiter.Check(ctx, "user@example.com", "10.0.0.3"); wait != 0 {
+		t.Fatalf("Expected lockout to expire, got %v", wait)
+	}
+	if wait, _ := limiter.Fail(ctx, "user@example.com", "10.0.0.3"); wait != 20*time.Second {
+		t.Fatalf("Expected backoff to double, got %v", wait)
+	}
+}
+
+func TestLimiter_LocksIPAcrossAccounts(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestLimiter()
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.Fail(ctx, "user"+string(rune('a'+i))+"@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Fail failed: %v", err)
+		}
+	}
+	if wait, _ := limiter.Check(ctx, "fresh@example.com", "10.0.0.1"); wait == 0 {
+		t.Fatal("Expected IP to be locked after spraying accounts")
+	}
+	if wait, _ := limiter.Check(ctx, "fresh@example.com", "10.0.0.2"); wait != 0 {
+		t.Fatalf("Expected other IPs to be unaffected, got %v", wait)
+	}
+}
+
+func TestLimiter_FailuresExpireAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestLimiter()
+	limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+	limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+	clock.now = clock.now.Add(2 * time.Hour)
+	if wait, _ := limiter.Fail(ctx, "user@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("Expected old failures to be forgotten, got %v", wait)
+	}
+}
+
+func TestLimiter_SucceedAndUnlockResetAccount(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestLimiter()
+	for i := 0; i < 3; i++ {
+		limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+	}
+	if err := limiter.Unlock(ctx, "user@example.com"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if wait, _ := limiter.Check(ctx, "user@example.com", "10.0.0.9"); wait != 0 {
+		t.Fatalf("Expected account to be unlocked, got %v", wait)
+	}
+
+	limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+	limiter.Fail(ctx, "user@example.com", "10.0.0.1")
+	limiter.Succeed(ctx, "user@example.com")
+	if wait, _ := limiter.Fail(ctx, "user@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("Expected success to reset the failure count, got %v", wait)
+	}
+}
@@ -0,0 +1,68 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneEvery controls how often MemoryStore drops entries that are
+// neither locked nor inside their failure window.
+const pruneEvery = 1024
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	writes  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]Entry{}}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[key], nil
+}
+
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes++
+	if s.writes%pruneEvery == 0 {
+		s.prune(now, windowStart)
+	}
+	entry := s.entries[key]
+	if entry.LastFailureAt.Before(windowStart) {
+		entry.Failures = 0
+	}
+	entry.Failures++
+	entry.LastFailureAt = now
+	s.entries[key] = entry
+	return entry.Failures, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[key]
+	entry.LockedUntil = until
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) prune(now, windowStart time.Time) {
+	for key, entry := range s.entries {
+		if entry.LastFailureAt.Before(windowStart) && entry.LockedUntil.Before(now) {
+			delete(s.entries, key)
+		}
+	}
+}
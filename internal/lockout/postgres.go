@@ -0,0 +1,55 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+)
+
+// PostgresStore keeps entries in the login_attempts table so that all
+// replicas share them.
+type PostgresStore struct {
+	DB *database.Queries
+}
+
+func NewPostgresStore(db *database.Queries) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (Entry, error) {
+	attempt, err := s.DB.GetLoginAttempt(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Entry{}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Failures:      int(attempt.Failures),
+		LastFailureAt: attempt.LastFailureAt,
+		LockedUntil:   attempt.LockedUntil.Time,
+	}, nil
+}
+
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, now, windowStart time.Time) (int, error) {
+	failures, err := s.DB.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         key,
+		Now:         now,
+		WindowStart: windowStart,
+	})
+	return int(failures), err
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.DB.LockLoginAttempt(ctx, database.LockLoginAttemptParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: until, Valid: true},
+	})
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.DB.DeleteLoginAttempt(ctx, key)
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOutboxMailer_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewOutboxMailer(dir, "Chirpy <no-reply@chirpy.test>")
+	if err != nil {
+		t.Fatalf("NewOutboxMailer failed: %v", err)
+	}
+	msg := Message{
+		To:      "user@example.com",
+		Subject: "Passwort zurücksetzen",
+		Body:    "Hallo,\nhier ist dein Link: https://chirpy.test/reset?token=abc123",
+	}
+	if err := outbox.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	messages, err := ReadOutbox(dir)
+	if err != nil {
+		t.Fatalf("ReadOutbox failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	got := messages[0]
+	if got.To != msg.To || got.Subject != msg.Subject {
+		t.Errorf("Expected %q/%q, got %q/%q", msg.To, msg.Subject, got.To, got.Subject)
+	}
+	if got.Body != msg.Body {
+		t.Errorf("Unexpected body %q", got.Body)
+	}
+}
+
+func TestFormat_RejectsHeaderInjection(t *testing.T) {
+	msg := Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "hi",
+	}
+	if _, err := Format("no-reply@chirpy.test", msg, time.Now()); err == nil {
+		t.Fatal("Expected error for header with line break, got nil")
+	}
+}
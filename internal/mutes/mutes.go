@@ -0,0 +1,108 @@
+// Package mutes decides which chirps a user sees: chirps of users who
+// blocked them are hidden everywhere, chirps of muted users and chirps
+// containing muted words are left out of listings.
+package mutes
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxWordLength is the longest muted word or phrase, in characters.
+const MaxWordLength = 100
+
+var ErrInvalidWord = errors.New("muted words must be between 1 and 100 characters")
+
+// NormalizeWord returns word in the form it is stored and matched in.
+func NormalizeWord(word string) (string, error) {
+	word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+	if word == "" || utf8.RuneCountInString(word) > MaxWordLength {
+		return "", ErrInvalidWord
+	}
+	return word, nil
+}
+
+// Filter holds what one viewer does not want to see. The nil Filter, for
+// anonymous viewers, lets everything through.
+type Filter struct {
+	blockedBy map[uuid.UUID]bool
+	muted     map[uuid.UUID]bool
+	words     []string
+}
+
+// NewFilter returns the filter for a viewer blocked by blockedBy who muted
+// the users muted and the normalized words.
+func NewFilter(blockedBy, muted []uuid.UUID, words []string) *Filter {
+	f := &Filter{
+		blockedBy: make(map[uuid.UUID]bool, len(blockedBy)),
+		muted:     make(map[uuid.UUID]bool, len(muted)),
+		words:     words,
+	}
+	for _, id := range blockedBy {
+		f.blockedBy[id] = true
+	}
+	for _, id := range muted {
+		f.muted[id] = true
+	}
+	return f
+}
+
+// Hides reports whether author blocked the viewer.
+func (f *Filter) Hides(author uuid.UUID) bool {
+	return f != nil && f.blockedBy[author]
+}
+
+// Mutes reports whether a chirp by author with text is muted.
+func (f *Filter) Mutes(author uuid.UUID, text string) bool {
+	if f == nil {
+		return false
+	}
+	if f.muted[author] {
+		return true
+	}
+	if len(f.words) == 0 || text == "" {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, word := range f.words {
+		if containsWord(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether word occurs in text as a whole word, so
+// "cat" matches "a cat!" and "#cat" but not "concatenate".
+func containsWord(text, word string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if boundaryBefore(text, start) && boundaryAfter(text, end) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+}
+
+func boundaryBefore(text string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return i == 0 || !isWordRune(r)
+}
+
+func boundaryAfter(text string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return i == len(text) || !isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
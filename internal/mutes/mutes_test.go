@@ -0,0 +1,67 @@
+package mutes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeWord(t *testing.T) {
+	cases := map[string]string{
+		"Cat":                "cat",
+		"  Spoiler   ALERT ": "spoiler alert",
+		"#GoLang":            "#golang",
+	}
+	for in, want := range cases {
+		got, err := NormalizeWord(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeWord(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "   ", strings.Repeat("ä", MaxWordLength+1)} {
+		if _, err := NormalizeWord(in); err != ErrInvalidWord {
+			t.Errorf("NormalizeWord(%q) error = %v, want ErrInvalidWord", in, err)
+		}
+	}
+}
+
+func TestFilter_MutesWholeWords(t *testing.T) {
+	f := NewFilter(nil, nil, []string{"cat", "spoiler alert", "#go"})
+	author := uuid.New()
+	cases := map[string]bool{
+		"I love my Cat!":               true,
+		"#cat pictures":                true,
+		"concatenate strings":          false,
+		"cats":                         false,
+		"SPOILER ALERT: he dies":       true,
+		"spoiler alerts are nice":      false,
+		"learning #Go today":           true,
+		"let's go":                     false,
+		"ein Kätzchen, keine cat_mama": false,
+		"":                             false,
+	}
+	for text, want := range cases {
+		if got := f.Mutes(author, text); got != want {
+			t.Errorf("Mutes(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestFilter_Users(t *testing.T) {
+	blocker, muted, other := uuid.New(), uuid.New(), uuid.New()
+	f := NewFilter([]uuid.UUID{blocker}, []uuid.UUID{muted}, nil)
+	if !f.Hides(blocker) || f.Hides(muted) || f.Hides(other) {
+		t.Error("Hides is not limited to users who blocked the viewer")
+	}
+	if !f.Mutes(muted, "hello") || f.Mutes(other, "hello") {
+		t.Error("Mutes is not limited to muted users")
+	}
+}
+
+func TestFilter_NilLetsEverythingThrough(t *testing.T) {
+	var f *Filter
+	if f.Hides(uuid.New()) || f.Mutes(uuid.New(), "anything") {
+		t.Error("nil Filter filtered a chirp")
+	}
+}
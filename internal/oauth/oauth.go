@@ -0,0 +1,195 @@
+// Package oauth holds the protocol pieces of Chirpy's OAuth 2.1
+// authorization server: PKCE, scope strings, redirect URIs and client
+// authentication.
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Error codes from RFC 6749 section 4.1.2.1 and 5.2.
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrInvalidScope            = "invalid_scope"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrAccessDenied            = "access_denied"
+	ErrServerError             = "server_error"
+)
+
+// Error is an OAuth error response.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func NewError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// S256Challenge derives the PKCE code challenge of verifier (RFC 7636).
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ValidCodeVerifier reports whether verifier has the length and alphabet
+// RFC 7636 section 4.1 requires. Challenges use the same rules.
+func ValidCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyPKCE checks a code verifier against the S256 challenge stored
+// with the authorization code. OAuth 2.1 drops the "plain" method.
+func VerifyPKCE(verifier, challenge string) bool {
+	if !ValidCodeVerifier(verifier) {
+		return false
+	}
+	return S256Challenge(verifier) == challenge
+}
+
+// ParseScope splits a space-delimited scope parameter, dropping duplicates.
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, s := range strings.Fields(scope) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// FormatScope joins scopes into a scope parameter.
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Subset reports whether every scope in requested is in allowed.
+func Subset(requested, allowed []string) bool {
+	for _, r := range requested {
+		found := false
+		for _, a := range allowed {
+			if r == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRedirectURI checks a redirect URI at client registration. It
+// must be absolute without a fragment, and plain http is only allowed for
+// loopback addresses used by native apps (RFC 8252).
+func ValidateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URI %q: %v", uri, err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("redirect URI %q must be absolute", uri)
+	}
+	if u.Fragment != "" || strings.Contains(uri, "#") {
+		return fmt.Errorf("redirect URI %q must not contain a fragment", uri)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if u.Hostname() == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(u.Hostname()); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+		return fmt.Errorf("redirect URI %q must use https", uri)
+	}
+	return fmt.Errorf("redirect URI %q has unsupported scheme %q", uri, u.Scheme)
+}
+
+// MatchRedirectURI reports whether uri is one of the registered redirect
+// URIs. OAuth 2.1 requires exact string matching.
+func MatchRedirectURI(registered []string, uri string) bool {
+	for _, r := range registered {
+		if r == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendQuery adds params to the query of a redirect URI, keeping any
+// query it already has.
+func AppendQuery(uri string, params url.Values) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ClientCredentials reads the client ID and secret from HTTP Basic
+// authentication or, failing that, from the form body. Public clients
+// only send a client_id. The form must already be parsed.
+func ClientCredentials(r *http.Request) (clientID, secret string, err error) {
+	if id, pass, ok := r.BasicAuth(); ok {
+		// RFC 6749 section 2.3.1 form-encodes both parts before Basic.
+		clientID, err = url.QueryUnescape(id)
+		if err != nil {
+			return "", "", err
+		}
+		secret, err = url.QueryUnescape(pass)
+		if err != nil {
+			return "", "", err
+		}
+		if r.PostForm.Get("client_secret") != "" {
+			return "", "", errors.New("client authenticated with more than one method")
+		}
+		return clientID, secret, nil
+	}
+	clientID = r.PostForm.Get("client_id")
+	if clientID == "" {
+		return "", "", errors.New("missing client_id")
+	}
+	return clientID, r.PostForm.Get("client_secret"), nil
+}
@@ -0,0 +1,106 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got := S256Challenge(verifier); got != challenge {
+		t.Fatalf("Expected challenge %s, got %s", challenge, got)
+	}
+	if !VerifyPKCE(verifier, challenge) {
+		t.Error("Expected verifier to match its challenge")
+	}
+	if VerifyPKCE(strings.Repeat("a", 43), challenge) {
+		t.Error("Expected wrong verifier to be rejected")
+	}
+	if VerifyPKCE("short", S256Challenge("short")) {
+		t.Error("Expected verifier below 43 characters to be rejected")
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	scopes := ParseScope("  chirps:read chirps:write chirps:read ")
+	if len(scopes) != 2 || scopes[0] != "chirps:read" || scopes[1] != "chirps:write" {
+		t.Fatalf("Unexpected scopes %v", scopes)
+	}
+	if FormatScope(scopes) != "chirps:read chirps:write" {
+		t.Errorf("Unexpected scope string %q", FormatScope(scopes))
+	}
+	if ParseScope("") == nil {
+		t.Error("Expected empty scope to parse to an empty, non-nil slice")
+	}
+	if !Subset([]string{"chirps:read"}, scopes) || Subset([]string{"profile:write"}, scopes) {
+		t.Error("Subset returned the wrong answer")
+	}
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	valid := []string{
+		"https://app.example.com/callback",
+		"http://127.0.0.1:8765/callback",
+		"http://localhost/cb",
+	}
+	for _, uri := range valid {
+		if err := ValidateRedirectURI(uri); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", uri, err)
+		}
+	}
+	invalid := []string{
+		"http://app.example.com/callback",
+		"https://app.example.com/callback#frag",
+		"/callback",
+		"javascript:alert(1)",
+	}
+	for _, uri := range invalid {
+		if err := ValidateRedirectURI(uri); err == nil {
+			t.Errorf("Expected %s to be rejected", uri)
+		}
+	}
+	if MatchRedirectURI([]string{"https://app.example.com/cb"}, "https://app.example.com/cb/") {
+		t.Error("Expected redirect URIs to match exactly")
+	}
+}
+
+func TestAppendQuery(t *testing.T) {
+	got, err := AppendQuery("https://app.example.com/cb?tenant=1", url.Values{"code": {"abc"}, "state": {"x y"}})
+	if err != nil {
+		t.Fatalf("AppendQuery failed: %v", err)
+	}
+	if got != "https://app.example.com/cb?code=abc&state=x+y&tenant=1" {
+		t.Errorf("Unexpected URI %s", got)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=authorization_code"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth("client%3A1", "s%2Bcret")
+	if err := r.ParseForm(); err != nil {
+		t.Fatalf("ParseForm failed: %v", err)
+	}
+	id, secret, err := ClientCredentials(r)
+	if err != nil {
+		t.Fatalf("ClientCredentials failed: %v", err)
+	}
+	if id != "client:1" || secret != "s+cret" {
+		t.Errorf("Unexpected credentials %q %q", id, secret)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("client_id=public"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := r.ParseForm(); err != nil {
+		t.Fatalf("ParseForm failed: %v", err)
+	}
+	id, secret, err = ClientCredentials(r)
+	if err != nil || id != "public" || secret != "" {
+		t.Errorf("Unexpected public client credentials %q %q %v", id, secret, err)
+	}
+}
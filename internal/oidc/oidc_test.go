@@ -0,0 +1,177 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/oauth"
+	"github.com/LucaFe1337/Chipry/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	redirectURL = "http://localhost:8080/auth/oidc/corp/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+var identity = oidctest.Identity{
+	Subject:       "employee-42",
+	Email:         "ada@corp.example",
+	EmailVerified: true,
+	Name:          "Ada",
+}
+
+func newProvider(idp *oidctest.Server) *Provider {
+	return NewProvider(Config{
+		Name:         "corp",
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+	}, nil)
+}
+
+// login runs the browser part of the flow and returns the code.
+func login(t *testing.T, provider *Provider, nonce string) string {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, oauth.S256Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("GET authorize failed: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Parsing redirect failed: %v", err)
+	}
+	if !strings.HasPrefix(location.String(), redirectURL) || location.Query().Get("state") != "state-1" {
+		t.Fatalf("Unexpected redirect %s", location)
+	}
+	return location.Query().Get("code")
+}
+
+func TestProvider_LoginFlow(t *testing.T) {
+	idp := oidctest.NewServer("chirpy", "s3cret", identity)
+	defer idp.Close()
+	provider := newProvider(idp)
+	ctx := context.Background()
+
+	code := login(t, provider, "nonce-1")
+	raw, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	token, err := provider.VerifyIDToken(ctx, raw, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken failed: %v", err)
+	}
+	if token.Subject != identity.Subject || token.Email != identity.Email || !token.EmailVerified {
+		t.Errorf("Unexpected claims %+v", token)
+	}
+	if _, err := provider.VerifyIDToken(ctx, raw, "other-nonce"); err == nil {
+		t.Error("Expected error for mismatched nonce, got nil")
+	}
+	if _, err := provider.Exchange(ctx, code, verifier); err == nil {
+		t.Error("Expected error when redeeming a code twice, got nil")
+	}
+}
+
+func TestProvider_PublicClientAndWrongVerifier(t *testing.T) {
+	idp := oidctest.NewServer("chirpy-public", "", identity)
+	defer idp.Close()
+	provider := newProvider(idp)
+
+	code := login(t, provider, "nonce-1")
+	if _, err := provider.Exchange(context.Background(), code, strings.Repeat("x", 43)); err == nil {
+		t.Fatal("Expected error for wrong code verifier, got nil")
+	}
+	code = login(t, provider, "nonce-1")
+	if _, err := provider.Exchange(context.Background(), code, verifier); err != nil {
+		t.Fatalf("Exchange failed for public client: %v", err)
+	}
+}
+
+func TestProvider_RejectsForeignTokens(t *testing.T) {
+	idp := oidctest.NewServer("chirpy", "s3cret", identity)
+	defer idp.Close()
+	provider := newProvider(idp)
+	ctx := context.Background()
+	now := time.Now()
+
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   idp.Issuer(),
+			"sub":   "someone",
+			"aud":   "chirpy",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+			"nonce": "n",
+		}
+	}
+	cases := map[string]func(jwt.MapClaims){
+		"other audience": func(c jwt.MapClaims) { c["aud"] = "other-app" },
+		"other issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+		"foreign azp":    func(c jwt.MapClaims) { c["aud"] = []string{"chirpy", "other-app"}; c["azp"] = "other-app" },
+	}
+	for name, mutate := range cases {
+		claims := base()
+		mutate(claims)
+		raw, err := idp.SignIDToken(claims)
+		if err != nil {
+			t.Fatalf("SignIDToken failed: %v", err)
+		}
+		if _, err := provider.VerifyIDToken(ctx, raw, "n"); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+	raw, err := idp.SignIDToken(base())
+	if err != nil {
+		t.Fatalf("SignIDToken failed: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, raw, "n"); err != nil {
+		t.Errorf("Expected valid token to verify, got %v", err)
+	}
+}
+
+func TestProvider_RefetchesKeysAfterRotation(t *testing.T) {
+	defer func(interval time.Duration) { keyRefreshInterval = interval }(keyRefreshInterval)
+	keyRefreshInterval = 0
+
+	idp := oidctest.NewServer("chirpy", "s3cret", identity)
+	defer idp.Close()
+	provider := newProvider(idp)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		code := login(t, provider, "nonce-1")
+		raw, err := provider.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatalf("Exchange failed: %v", err)
+		}
+		if _, err := provider.VerifyIDToken(ctx, raw, "nonce-1"); err != nil {
+			t.Fatalf("VerifyIDToken failed after %d rotations: %v", i, err)
+		}
+		idp.RotateKey()
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("chirpy", "s3cret", identity)
+	defer idp.Close()
+	provider := NewProvider(Config{Name: "corp", Issuer: idp.Issuer() + "/", ClientID: "chirpy"}, nil)
+	if _, err := provider.Metadata(context.Background()); err == nil {
+		t.Fatal("Expected error for issuer mismatch, got nil")
+	}
+}
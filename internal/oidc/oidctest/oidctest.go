@@ -0,0 +1,204 @@
+// Package oidctest runs a minimal OpenID Connect provider for tests. It
+// signs in a fixed identity without asking and implements discovery,
+// JWKS, the authorization endpoint and the token endpoint with PKCE.
+package oidctest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/oauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity is the user the mock provider signs in.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type pendingCode struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// Server is a mock identity provider listening on a local port.
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu       sync.Mutex
+	identity Identity
+	keyring  *auth.Keyring
+	codes    map[string]pendingCode
+}
+
+// NewServer starts a provider that accepts the given client. An empty
+// secret makes it a public client.
+func NewServer(clientID, clientSecret string, identity Identity) *Server {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		identity:     identity,
+		codes:        map[string]pendingCode{},
+	}
+	s.RotateKey()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer is the issuer identifier of the provider.
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetIdentity changes who is signed in by the next login.
+func (s *Server) SetIdentity(identity Identity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identity = identity
+}
+
+// RotateKey replaces the signing key; tokens signed before stop validating.
+func (s *Server) RotateKey() {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	keyring := auth.NewKeyring("", time.Time{})
+	if _, err := keyring.AddKey(key, false); err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyring = keyring
+}
+
+// SignIDToken signs arbitrary claims with the current key, for tests of
+// malformed or foreign tokens.
+func (s *Server) SignIDToken(claims jwt.Claims) (string, error) {
+	s.mu.Lock()
+	keyring := s.keyring
+	s.mu.Unlock()
+	return keyring.Sign(claims)
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.Issuer(),
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{auth.AlgEdDSA},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	keyring := s.keyring
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, keyring.JWKS())
+}
+
+// authorize signs the configured identity in right away and redirects
+// back with a code.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = pendingCode{
+		redirectURI: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+	}
+	s.mu.Unlock()
+	location, err := oauth.AppendQuery(query.Get("redirect_uri"), url.Values{"code": {code}, "state": {query.Get("state")}})
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, oauth.NewError(oauth.ErrInvalidRequest, ""))
+		return
+	}
+	clientID, secret, err := oauth.ClientCredentials(r)
+	if err != nil || clientID != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, oauth.NewError(oauth.ErrInvalidClient, ""))
+		return
+	}
+	s.mu.Lock()
+	pending, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	identity := s.identity
+	s.mu.Unlock()
+	if !ok || pending.redirectURI != r.PostForm.Get("redirect_uri") ||
+		!oauth.VerifyPKCE(r.PostForm.Get("code_verifier"), pending.challenge) {
+		writeJSON(w, http.StatusBadRequest, oauth.NewError(oauth.ErrInvalidGrant, ""))
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.Issuer(),
+		"sub":            identity.Subject,
+		"aud":            s.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          pending.nonce,
+		"email":          identity.Email,
+		"email_verified": identity.EmailVerified,
+		"name":           identity.Name,
+	}
+	id_token, err := s.SignIDToken(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     id_token,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
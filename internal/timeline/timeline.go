@@ -0,0 +1,142 @@
+// Home timelines and their pagination cursors.
+//
+// Timelines are built with a hybrid of fan-out on write and fan-in on
+// read. Posting a chirp writes an entry into the timeline of every
+// follower, so reading a timeline is one range scan of an index no matter
+// how many accounts the reader follows. Authors with more than
+// MaxFanOutFollowers followers are skipped on write, since one of their
+// chirps would cost that many rows; their chirps are read from the chirps
+// table instead and merged in with Merge. See the Merge benchmarks for how
+// merging fanned-in chirps grows with the number of followed accounts.
+package timeline
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+// MaxFanOutFollowers is the follower count up to which chirps are fanned
+// out on write.
+const MaxFanOutFollowers = 10000
+
+var ErrInvalidCursor = errors.New("invalid timeline cursor")
+
+// Cursor is the position of a chirp in a timeline, which is ordered by
+// creation time and then ID, both descending. The zero Cursor is the
+// start of the timeline.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CursorOf returns the cursor that continues after chirp.
+func CursorOf(chirp database.Chirp) Cursor {
+	return Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+}
+
+// IsZero reports whether c is the start of the timeline.
+func (c Cursor) IsZero() bool {
+	return c.CreatedAt.IsZero() && c.ID == uuid.Nil
+}
+
+// Bounds returns the exclusive upper bound for the (created_at, id) keyset
+// queries. At the start of the timeline that is the largest possible key.
+func (c Cursor) Bounds() (time.Time, uuid.UUID) {
+	if c.IsZero() {
+		return time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), uuid.Max
+	}
+	return c.CreatedAt, c.ID
+}
+
+// Encode returns c as an opaque string for URLs.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + "." + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a cursor made by Encode.
+func Decode(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: time.UnixMicro(usec).UTC(), ID: parsed}, nil
+}
+
+// before reports whether a comes before b in a timeline.
+func before(a, b database.Chirp) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
+
+type stream struct {
+	chirps []database.Chirp
+	next   int
+}
+
+type streamHeap []*stream
+
+func (h streamHeap) Len() int { return len(h) }
+func (h streamHeap) Less(i, j int) bool {
+	return before(h[i].chirps[h[i].next], h[j].chirps[h[j].next])
+}
+func (h streamHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *streamHeap) Push(x any)   { *h = append(*h, x.(*stream)) }
+func (h *streamHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// Merge combines lists that are each in timeline order into the first
+// limit chirps of their union, dropping chirps that appear in more than
+// one list.
+func Merge(limit int, lists ...[]database.Chirp) []database.Chirp {
+	h := make(streamHeap, 0, len(lists))
+	for _, list := range lists {
+		if len(list) > 0 {
+			h = append(h, &stream{chirps: list})
+		}
+	}
+	heap.Init(&h)
+	merged := make([]database.Chirp, 0, limit)
+	seen := make(map[uuid.UUID]bool, limit)
+	for len(merged) < limit && h.Len() > 0 {
+		s := h[0]
+		chirp := s.chirps[s.next]
+		if !seen[chirp.ID] {
+			seen[chirp.ID] = true
+			merged = append(merged, chirp)
+		}
+		s.next++
+		if s.next == len(s.chirps) {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,121 @@
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+var epoch = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// authorChirps returns n chirps by one author in timeline order, posted
+// every step starting at offset.
+func authorChirps(n int, offset, step time.Duration) []database.Chirp {
+	author := uuid.New()
+	chirps := make([]database.Chirp, n)
+	for i := range chirps {
+		chirps[i] = database.Chirp{
+			ID:        uuid.New(),
+			UserID:    uuid.NullUUID{UUID: author, Valid: true},
+			CreatedAt: epoch.Add(-offset - time.Duration(i)*step),
+		}
+	}
+	return chirps
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	want := Cursor{CreatedAt: epoch.Add(123456 * time.Microsecond), ID: uuid.New()}
+	got, err := Decode(want.Encode())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("Decode(Encode(%v)) = %v", want, got)
+	}
+}
+
+func TestDecode_RejectsGarbage(t *testing.T) {
+	for _, s := range []string{"", "!!!", "bm9kb3Q", "YWJjLmRlZg", "MTIzLm5vdC1hLXV1aWQ"} {
+		if _, err := Decode(s); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestCursor_ZeroBoundsAfterEverything(t *testing.T) {
+	var c Cursor
+	if !c.IsZero() {
+		t.Fatal("zero Cursor is not IsZero")
+	}
+	createdAt, id := c.Bounds()
+	if !createdAt.After(time.Now().AddDate(100, 0, 0)) || id != uuid.Max {
+		t.Errorf("Bounds() = %v, %v", createdAt, id)
+	}
+}
+
+func TestMerge_InterleavesDedupesAndLimits(t *testing.T) {
+	a := authorChirps(5, 0, 2*time.Minute)
+	b := authorChirps(5, time.Minute, 2*time.Minute)
+	// A chirp that was fanned out before its author passed the threshold
+	// shows up in both lists.
+	b = append([]database.Chirp{a[0]}, b...)
+
+	merged := Merge(6, a, b, nil)
+	if len(merged) != 6 {
+		t.Fatalf("len = %d, want 6", len(merged))
+	}
+	want := []database.Chirp{a[0], b[1], a[1], b[2], a[2], b[3]}
+	for i := range want {
+		if merged[i].ID != want[i].ID {
+			t.Errorf("merged[%d] = %v, want %v", i, merged[i].CreatedAt, want[i].CreatedAt)
+		}
+	}
+}
+
+func TestMerge_SameTimeOrdersByID(t *testing.T) {
+	low := database.Chirp{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), CreatedAt: epoch}
+	high := database.Chirp{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), CreatedAt: epoch}
+	merged := Merge(2, []database.Chirp{low}, []database.Chirp{high})
+	if merged[0].ID != high.ID || merged[1].ID != low.ID {
+		t.Errorf("chirps posted at the same time are not in descending ID order")
+	}
+}
+
+// BenchmarkMergeFanIn merges a page from the newest chirps of every
+// followed account, the merge reading a timeline needs without fan-out.
+// Only the merge is measured; the database does the same work per
+// followed account on top of it.
+func BenchmarkMergeFanIn(b *testing.B) {
+	for _, following := range []int{10, 100, 1000, 10000} {
+		lists := make([][]database.Chirp, following)
+		for i := range lists {
+			lists[i] = authorChirps(20, time.Duration(i)*time.Second, time.Hour)
+		}
+		b.Run(fmt.Sprintf("following=%d", following), func(b *testing.B) {
+			for b.Loop() {
+				Merge(50, lists...)
+			}
+		})
+	}
+}
+
+// BenchmarkMergeFanOut merges a page of a precomputed timeline with the
+// chirps of a few popular accounts, the merge of the read path with
+// fan-out on write. Its cost does not depend on how many accounts are
+// followed.
+func BenchmarkMergeFanOut(b *testing.B) {
+	for _, popular := range []int{0, 5, 50} {
+		lists := [][]database.Chirp{authorChirps(50, 0, time.Minute)}
+		for i := 0; i < popular; i++ {
+			lists = append(lists, authorChirps(50, time.Duration(i)*time.Second, time.Hour))
+		}
+		b.Run(fmt.Sprintf("popular=%d", popular), func(b *testing.B) {
+			for b.Loop() {
+				Merge(50, lists...)
+			}
+		})
+	}
+}
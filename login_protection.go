@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+// checkLockout rejects the request with 429 if the account or the client
+// IP is locked out, and reports whether it did.
+func (cfg *apiConfig) checkLockout(w http.ResponseWriter, r *http.Request, email string) bool {
+	wait, err := cfg.Lockout.Check(r.Context(), email, cfg.clientIP(r))
+	if err != nil {
+		// Failing open keeps logins working if the store is unavailable.
+		log.Printf("error checking login lockout: %v", err)
+		return false
+	}
+	if wait <= 0 {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, "Too many failed logins, try again later")
+	return true
+}
+
+// recordLoginFailure counts a failed attempt towards the lockout and
+// audits it. The event names the account when one has the email.
+func (cfg *apiConfig) recordLoginFailure(r *http.Request, email string) {
+	event := audit.Event{
+		Type: audit.LoginFailure,
+		Data: map[string]string{"email": email},
+	}
+	if user, err := cfg.DB.GetPasswordFromEmail(r.Context(), email); err == nil {
+		event.SubjectID = user.ID
+	}
+	cfg.audit(r, event)
+	wait, err := cfg.Lockout.Fail(r.Context(), email, cfg.clientIP(r))
+	if err != nil {
+		log.Printf("error recording failed login: %v", err)
+		return
+	}
+	if wait > 0 {
+		log.Printf("login locked for %s from %s for %s", email, cfg.clientIP(r), wait.Round(time.Second))
+	}
+}
+
+// recordLoginSuccess clears failed attempts and audits the login. method
+// names how the user proved their identity.
+func (cfg *apiConfig) recordLoginSuccess(r *http.Request, user database.User, method string) {
+	cfg.audit(r, audit.Event{
+		Type:      audit.LoginSuccess,
+		ActorID:   user.ID,
+		SubjectID: user.ID,
+		Data:      map[string]string{"method": method},
+	})
+	if err := cfg.Lockout.Succeed(r.Context(), user.Email); err != nil {
+		log.Printf("error clearing failed logins: %v", err)
+	}
+}
+
+func (cfg *apiConfig) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if err := cfg.Lockout.Unlock(r.Context(), user.Email); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unlocking user")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.AdminUnlock,
+		ActorID:   callerID(r),
+		SubjectID: userID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/LucaFe1337/Chipry/internal/audit"
 	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/blob"
+	"github.com/LucaFe1337/Chipry/internal/chirptext"
 	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/lockout"
+	"github.com/LucaFe1337/Chipry/internal/mailer"
+	"github.com/LucaFe1337/Chipry/internal/oidc"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -21,25 +33,181 @@ import (
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	DB             *database.Queries
+	Conn           *sql.DB
 	PLATFORM       string
 	Secret         string
 	POLKA_API_KEY  string
+	Keys           *auth.Keyring
+	// TrustProxyHeaders makes clientIP honour X-Forwarded-For.
+	TrustProxyHeaders bool
+	Mailer            mailer.Mailer
+	// BaseURL is the public origin used in links sent by email.
+	BaseURL string
+	// RequireVerifiedEmail blocks posting chirps until the email is confirmed.
+	RequireVerifiedEmail bool
+	Passwords            *auth.PasswordHasher
+	Lockout              *lockout.Limiter
+	// OIDCProviders are the external identity providers users can sign in with.
+	OIDCProviders map[string]*oidc.Provider
+	Audit         *audit.Logger
+	// DeletionGracePeriod is how long a deleted account can still be
+	// restored by signing in.
+	DeletionGracePeriod time.Duration
+	// EditWindow is how long after posting authors may edit a chirp;
+	// Chirpy Red members get RedEditWindow.
+	EditWindow    time.Duration
+	RedEditWindow time.Duration
+	// MaxChirpLength is the limit for chirps in characters; Chirpy Red
+	// members get RedMaxChirpLength.
+	MaxChirpLength    int
+	RedMaxChirpLength int
+	// Blobs holds uploaded images, served below MediaBaseURL.
+	Blobs        blob.Store
+	MediaBaseURL string
+	// MediaUploadTTL is how long chirp media may stay unattached.
+	MediaUploadTTL time.Duration
 }
 
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Email     string    `json:"email"`
-	Red       bool      `json:"is_chirpy_red"`
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Email      string    `json:"email"`
+	Handle     string    `json:"handle,omitempty"`
+	Red        bool      `json:"is_chirpy_red"`
+	IsVerified bool      `json:"is_verified"`
+	Role       string    `json:"role"`
+	// PendingEmail is set while an email change awaits confirmation.
+	PendingEmail string `json:"pending_email,omitempty"`
 }
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	User_id   uuid.UUID `json:"user_id"`
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Body           string     `json:"body"`
+	User_id        uuid.UUID  `json:"user_id"`
+	InReplyTo      *uuid.UUID `json:"in_reply_to"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	ReplyCount     int32      `json:"reply_count"`
+	// Deleted marks the tombstone left in place of a deleted chirp that
+	// has replies; its body is empty, and so is its user_id (the nil
+	// UUID) once the author's account is deleted.
+	Deleted   bool             `json:"deleted,omitempty"`
+	LikeCount int64            `json:"like_count"`
+	Reactions map[string]int64 `json:"reactions"`
+	// Kind is "chirp", "rechirp" or "quote". Rechirps share
+	// ReferencedChirp as is and have an empty body; quotes add commentary.
+	Kind              string     `json:"kind"`
+	ReferencedChirpID *uuid.UUID `json:"referenced_chirp_id,omitempty"`
+	ReferencedChirp   *Chirp     `json:"referenced_chirp,omitempty"`
+	// ReferencedChirpUnavailable is set when the referenced chirp was
+	// deleted.
+	ReferencedChirpUnavailable bool `json:"referenced_chirp_unavailable,omitempty"`
+	// EditedAt is set once the body was edited; see the revisions.
+	EditedAt *time.Time `json:"edited_at"`
+	Media    []Media    `json:"media,omitempty"`
+	Entities Entities   `json:"entities"`
+}
+
+func chirpFromDB(chirp database.Chirp) Chirp {
+	return Chirp{
+		ID:                chirp.ID,
+		CreatedAt:         chirp.CreatedAt,
+		UpdatedAt:         chirp.UpdatedAt,
+		Body:              chirp.Body,
+		User_id:           chirp.UserID.UUID,
+		InReplyTo:         optionalUUID(chirp.InReplyTo),
+		ConversationID:    chirp.ConversationID,
+		ReplyCount:        chirp.ReplyCount,
+		Deleted:           chirp.DeletedAt.Valid,
+		Reactions:         map[string]int64{},
+		Kind:              chirp.Kind,
+		ReferencedChirpID: optionalUUID(chirp.ReferencedChirpID),
+		EditedAt:          optionalTime(chirp.EditedAt),
+		Entities:          emptyEntities(),
+	}
+}
+
+// hydrateChirps converts chirps for a response. It embeds the chirps that
+// rechirps and quotes refer to, and adds like and reaction counts; each is
+// read in one query for the whole batch.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, chirps []database.Chirp) ([]Chirp, error) {
+	refIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.ReferencedChirpID.Valid {
+			refIDs = append(refIDs, chirp.ReferencedChirpID.UUID)
+		}
+	}
+	all := chirps
+	if len(refIDs) > 0 {
+		refs, err := cfg.DB.GetChirpsByIds(ctx, refIDs)
+		if err != nil {
+			return nil, err
+		}
+		all = append(append([]database.Chirp{}, chirps...), refs...)
+	}
+	counted, err := cfg.countReactions(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.loadMedia(ctx, counted); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadEntities(ctx, counted); err != nil {
+		return nil, err
+	}
+
+	resp := counted[:len(chirps)]
+	refs := map[uuid.UUID]Chirp{}
+	for _, ref := range counted[len(chirps):] {
+		if !ref.Deleted {
+			refs[ref.ID] = ref
+		}
+	}
+	for i := range resp {
+		if resp[i].Kind == chirpKindChirp {
+			continue
+		}
+		ref, ok := refs[chirps[i].ReferencedChirpID.UUID]
+		if !chirps[i].ReferencedChirpID.Valid || !ok {
+			resp[i].ReferencedChirpUnavailable = true
+			continue
+		}
+		resp[i].ReferencedChirp = &ref
+	}
+	return resp, nil
+}
+
+// countReactions converts chirps and adds their like and reaction counts.
+func (cfg *apiConfig) countReactions(ctx context.Context, chirps []database.Chirp) ([]Chirp, error) {
+	resp := make([]Chirp, 0, len(chirps))
+	index := make(map[uuid.UUID][]int, len(chirps))
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		if _, ok := index[chirp.ID]; !ok {
+			ids = append(ids, chirp.ID)
+		}
+		index[chirp.ID] = append(index[chirp.ID], len(resp))
+		resp = append(resp, chirpFromDB(chirp))
+	}
+	if len(ids) == 0 {
+		return resp, nil
+	}
+	counts, err := cfg.DB.CountChirpReactions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		for _, i := range index[count.ChirpID] {
+			if count.Reaction == reactionLike {
+				resp[i].LikeCount = count.Count
+			} else {
+				resp[i].Reactions[count.Reaction] = count.Count
+			}
+		}
+	}
+	return resp, nil
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -62,13 +230,19 @@ func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, htmlResponse)
 }
 
+// resetMetrics needs the admin:reset permission.
 func (cfg *apiConfig) resetMetrics(w http.ResponseWriter, r *http.Request) {
+	// Deliberate safety guard, not authorization: requirePermission already
+	// decides who may call this, but the reset deletes every user, so it
+	// stays disabled outside the dev platform even for admins to keep a
+	// stray request from wiping production data.
 	if cfg.PLATFORM == "dev" {
 		cfg.fileserverHits.Store(0)
 		err := cfg.DB.DeleteAllUsers(r.Context())
 		if err != nil {
 			fmt.Fprint(w, "Error deleting users!")
 		}
+		cfg.audit(r, audit.Event{Type: audit.AdminReset, ActorID: callerID(r)})
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "Count reset!")
 	} else {
@@ -106,14 +280,25 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(dat)
 }
 
-func validateChirps(chirpText string) (string, error) {
-	maxLength := 140
+const (
+	defaultMaxChirpLength    = 140
+	defaultRedMaxChirpLength = 280
+	// maxChirpRequestBytes bounds the JSON of a chirp request. It leaves
+	// room for a chirp at the default limits made of escaped emoji
+	// sequences.
+	maxChirpRequestBytes = 32 << 10
+)
 
-	if len(chirpText) > maxLength {
-		// Chirp ist zu lang
-		return "", fmt.Errorf("Chirp is too long. Max length is %d characters.", maxLength)
+// validateChirps checks and cleans the text the author wrote. For quote
+// chirps that is only the commentary; the quoted chirp is referenced, not
+// copied into the body, so it doesn't count against the limit. Length is
+// counted as chirptext.Length does.
+func validateChirps(chirpText string, maxLength int) (string, error) {
+	normalized, err := chirptext.Validate(chirpText, maxLength)
+	if err != nil {
+		return "", err
 	}
-	cleanedChirp := cleanChirp(chirpText)
+	cleanedChirp := cleanChirp(normalized)
 
 	type successResponse struct {
 		Cleaned_Body string `json:"cleaned_body"`
@@ -122,6 +307,49 @@ func validateChirps(chirpText string) (string, error) {
 	return cleanedChirp, nil
 }
 
+// maxChirpLength is the limit for chirps of user.
+func (cfg *apiConfig) maxChirpLength(user database.User) int {
+	if user.IsChirpyRed {
+		return cfg.RedMaxChirpLength
+	}
+	return cfg.MaxChirpLength
+}
+
+// respondWithChirpError reports why validateChirps rejected a chirp.
+// Chirps over the limit get its length and how much has to go.
+func respondWithChirpError(w http.ResponseWriter, err error) {
+	var tooLong *chirptext.TooLongError
+	if errors.As(err, &tooLong) {
+		respondWithJSON(w, http.StatusBadRequest, struct {
+			Error     string `json:"error"`
+			Length    int    `json:"length"`
+			MaxLength int    `json:"max_length"`
+			Remaining int    `json:"remaining"`
+		}{
+			Error:     err.Error(),
+			Length:    tooLong.Length,
+			MaxLength: tooLong.Max,
+			Remaining: tooLong.Remaining(),
+		})
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, err.Error())
+}
+
+// decodeChirpJSON decodes a request with chirp text into v. JSON decoding
+// would quietly replace invalid UTF-8, so the body is checked first. Bodies
+// over maxChirpRequestBytes get an *http.MaxBytesError.
+func decodeChirpJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChirpRequestBytes))
+	if err != nil {
+		return err
+	}
+	if !utf8.Valid(body) {
+		return chirptext.ErrInvalidUTF8
+	}
+	return json.Unmarshal(body, v)
+}
+
 func cleanChirp(text string) string {
 	badWords := map[string]string{
 		"kerfuffle": "****",
@@ -155,9 +383,14 @@ func (cfg *apiConfig) createNewUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateEmail(param.Email); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var userParams database.CreateUserParams
 	userParams.Email = param.Email
-	userParams.HashedPassword, err = auth.HashPassword(param.Password)
+	userParams.HashedPassword, err = cfg.Passwords.Hash(param.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Erorr trying to Hash the password!")
 		return
@@ -168,65 +401,197 @@ func (cfg *apiConfig) createNewUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "smth went wrong Creating the user!")
 		return
 	}
+	if err := cfg.sendEmailVerification(r.Context(), user.ID, user.Email); err != nil {
+		log.Printf("error sending verification email to user %s: %v", user.ID, err)
+	}
 
 	resp := User{
 		ID:        user.ID,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 		Email:     user.Email,
+		Role:      user.Role,
 	}
 	respondWithJSON(w, http.StatusCreated, resp)
 }
 
+// checkCanPost enforces RequireVerifiedEmail and responds if userID may
+// not post.
+func (cfg *apiConfig) checkCanPost(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	if !cfg.RequireVerifiedEmail {
+		return true
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return false
+	}
+	if !user.VerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "verify your email address before posting chirps")
+		return false
+	}
+	return true
+}
+
 func (cfg *apiConfig) postChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Body string `json:"body"`
+		// InReplyTo makes the chirp a reply in the conversation of that chirp.
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
+		// QuoteOf makes the chirp a quote; Body is the commentary.
+		QuoteOf *uuid.UUID `json:"quote_of"`
+		// MediaIDs are uploads from POST /api/media, in display order.
+		MediaIDs []uuid.UUID `json:"media_ids"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	param := parameters{}
-	err := decoder.Decode(&param)
+	err := decodeChirpJSON(w, r, &param)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+		return
+	}
+	if errors.Is(err, chirptext.ErrInvalidUTF8) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
-	token_string, err := auth.GetBearerToken(r.Header)
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		respondWithAuthError(w, err)
+		return
+	}
+	userID := caller.UserID
+	if !cfg.checkCanPost(w, r, userID) {
+		return
+	}
+	if !cfg.checkMedia(w, r, userID, param.MediaIDs) {
 		return
 	}
-	userID, err := auth.ValidateJWT(token_string, cfg.Secret)
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		respondWithError(w, http.StatusUnauthorized, "user not found")
 		return
 	}
 
-	chirpText, err := validateChirps(param.Body)
+	chirpText, err := validateChirps(param.Body, cfg.maxChirpLength(user))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Valdation of Chirp failed!")
+		respondWithChirpError(w, err)
 		return
 	}
 	var chirpdata database.CreateChirpsParams
+	chirpdata.ID = uuid.New()
 	chirpdata.Body = chirpText
-	chirpdata.UserID = userID
-	chirp, err := cfg.DB.CreateChirps(r.Context(), chirpdata)
+	chirpdata.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	chirpdata.ConversationID = chirpdata.ID
+	chirpdata.Kind = chirpKindChirp
+	if param.QuoteOf != nil {
+		if strings.TrimSpace(chirpText) == "" {
+			respondWithError(w, http.StatusBadRequest, "a quote needs commentary, rechirp instead")
+			return
+		}
+		quoted, err := cfg.referencedChirp(r.Context(), *param.QuoteOf)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "chirp to quote not found")
+			return
+		}
+		if blocked, err := cfg.blockedBy(r.Context(), quoted.UserID.UUID, userID); err != nil || blocked {
+			respondWithError(w, http.StatusNotFound, "chirp to quote not found")
+			return
+		}
+		chirpdata.Kind = chirpKindQuote
+		chirpdata.ReferencedChirpID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+	if param.InReplyTo != nil {
+		parent, err := cfg.DB.GetChirpById(r.Context(), *param.InReplyTo)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "chirp to reply to not found")
+			return
+		}
+		blocked, err := cfg.blockedBy(r.Context(), parent.UserID.UUID, userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "you can't reply to this user")
+			return
+		}
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if param.InReplyTo != nil {
+		// Counting the reply locks the parent, so it can't be deleted
+		// without leaving a tombstone while the reply is created.
+		conversationID, err := qtx.AddChirpReply(r.Context(), *param.InReplyTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "chirp to reply to not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+			return
+		}
+		chirpdata.InReplyTo = uuid.NullUUID{UUID: *param.InReplyTo, Valid: true}
+		chirpdata.ConversationID = conversationID
+	}
+	chirp, err := qtx.CreateChirps(r.Context(), chirpdata)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
 		return
 	}
-	resp := Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		User_id:   chirp.UserID,
+	if len(param.MediaIDs) > 0 {
+		attached, err := qtx.AttachMedia(r.Context(), database.AttachMediaParams{
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Ids:     param.MediaIDs,
+			UserID:  uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+			return
+		}
+		if attached != int64(len(param.MediaIDs)) {
+			respondWithError(w, http.StatusConflict, "media is already attached to a chirp")
+			return
+		}
 	}
-	respondWithJSON(w, http.StatusCreated, resp)
+	if err := storeEntities(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+		return
+	}
+	if err := fanOut(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+		return
+	}
+	resp, err := cfg.hydrateChirps(r.Context(), []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "smth went wrong Creating the Chirp!")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resp[0])
 }
 
 func (cfg *apiConfig) GetAllChirps(w http.ResponseWriter, r *http.Request) {
 	author_id := r.URL.Query().Get("author_id")
 	sorting := r.URL.Query().Get("sort")
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
+	}
 	var chirps []database.Chirp
 	if author_id == "" {
 		var innererror error
@@ -241,24 +606,19 @@ func (cfg *apiConfig) GetAllChirps(w http.ResponseWriter, r *http.Request) {
 			respondWithError(w, http.StatusInternalServerError, "Error parsing author id to uuid")
 			return
 		}
-		chirps, err = cfg.DB.AllchirpsFromUser(r.Context(), parsed_user_id)
+		chirps, err = cfg.DB.AllchirpsFromUser(r.Context(), uuid.NullUUID{UUID: parsed_user_id, Valid: true})
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps from specified user")
 			return
 		}
 	}
 
-	var allChirps []Chirp
-	for _, chirp := range chirps {
-		newChirp := Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			User_id:   chirp.UserID,
-		}
-		allChirps = append(allChirps, newChirp)
+	allChirps, err := cfg.hydrateChirps(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
 	}
+	allChirps = filterChirps(filter, allChirps, true)
 	if sorting == "desc" {
 		sort.Slice(allChirps, func(i, j int) bool { return allChirps[i].CreatedAt.After(allChirps[j].CreatedAt) })
 	}
@@ -272,19 +632,27 @@ func (cfg *apiConfig) getChipById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp")
+		return
+	}
+
 	chirp, err := cfg.DB.GetChirpById(r.Context(), id)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
 		return
 	}
-	newChirp := Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		User_id:   chirp.UserID,
+	resp, err := cfg.hydrateChirps(r.Context(), []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirp")
+		return
+	}
+	if !visible(filter, &resp[0], false) {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
 	}
-	respondWithJSON(w, http.StatusOK, newChirp)
+	respondWithJSON(w, http.StatusOK, resp[0])
 }
 
 func (cfg *apiConfig) authenticateLogin(w http.ResponseWriter, r *http.Request) {
@@ -301,52 +669,105 @@ func (cfg *apiConfig) authenticateLogin(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
+	if cfg.checkLockout(w, r, param.Email) {
+		return
+	}
 	user, err := cfg.DB.GetPasswordFromEmail(r.Context(), param.Email)
 	if err != nil {
+		cfg.recordLoginFailure(r, param.Email)
 		respondWithError(w, http.StatusUnauthorized, "Error retrieving user data")
 		return
 	}
-	err = auth.CheckPassword(user.HashedPassword, param.Password)
+	if user.HashedPassword == "" {
+		respondWithError(w, http.StatusUnauthorized, "Account signs in through an external identity provider")
+		return
+	}
+	err = cfg.Passwords.Check(user.HashedPassword, param.Password)
 	if err != nil {
+		cfg.recordLoginFailure(r, param.Email)
 		respondWithError(w, http.StatusUnauthorized, "Wrong Password!")
 		return
 	}
-	token_string, err := auth.MakeJWT(user.ID, cfg.Secret, time.Duration(3600)*time.Second)
+	if cfg.Passwords.NeedsRehash(user.HashedPassword) {
+		cfg.rehashPassword(r, user.ID, param.Password)
+	}
+	if user.TotpEnabledAt.Valid {
+		cfg.startMFAChallenge(w, user)
+		return
+	}
+	cfg.recordLoginSuccess(r, user, "password")
+	cfg.issueLogin(w, r, user)
+}
+
+// rehashPassword replaces a legacy or outdated hash after the password
+// was verified. Failing to do so doesn't fail the login.
+func (cfg *apiConfig) rehashPassword(r *http.Request, userID uuid.UUID, password string) {
+	hashed_password, err := cfg.Passwords.Hash(password)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error creating token string")
+		log.Printf("error rehashing password of user %s: %v", userID, err)
 		return
 	}
+	err = cfg.DB.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashed_password,
+	})
+	if err != nil {
+		log.Printf("error storing rehashed password of user %s: %v", userID, err)
+	}
+}
+
+// issueLogin starts a new session for user and responds with the access
+// and refresh tokens. Signing in cancels a pending account deletion.
+func (cfg *apiConfig) issueLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	cfg.cancelUserDeletion(r, user)
 	token, _ := auth.MakeRefreshToken()
 	refresh_token_params := database.CreateRefreshTokenParams{
 		Token:     token,
 		UserID:    user.ID,
 		ExpiresAt: time.Now().AddDate(0, 0, 60),
+		FamilyID:  uuid.New(),
+		UserAgent: r.UserAgent(),
+		Ip:        cfg.clientIP(r),
 	}
 	refresh_token, err := cfg.DB.CreateRefreshToken(r.Context(), refresh_token_params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating refresh token")
 		return
 	}
+	token_string, err := cfg.makeAccessToken(user.ID, refresh_token.FamilyID, user.Role)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating token string")
+		return
+	}
 	resp := struct {
 		ID           uuid.UUID `json:"id"`
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
 		Email        string    `json:"email"`
+		Handle       string    `json:"handle,omitempty"`
 		Token        string    `json:"token"`
 		RefreshToken string    `json:"refresh_token"`
 		Red          bool      `json:"is_chirpy_red"`
+		IsVerified   bool      `json:"is_verified"`
+		Role         string    `json:"role"`
 	}{
 		ID:           user.ID,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 		Email:        user.Email,
+		Handle:       user.Handle.String,
 		Token:        token_string,
 		RefreshToken: refresh_token.Token,
 		Red:          user.IsChirpyRed,
+		IsVerified:   user.VerifiedAt.Valid,
+		Role:         user.Role,
 	}
 	respondWithJSON(w, http.StatusOK, resp)
 }
 
+// refreshToken exchanges a refresh token for a new access token and a new
+// refresh token. Every refresh token can be used once; presenting one that
+// was already rotated means it leaked, so the whole family is revoked.
 func (cfg *apiConfig) refreshToken(w http.ResponseWriter, r *http.Request) {
 	refresh_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -360,6 +781,10 @@ func (cfg *apiConfig) refreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if data.RevokedAt.Valid {
+		if data.ReplacedBy.Valid {
+			cfg.revokeTokenFamily(w, r, data)
+			return
+		}
 		respondWithError(w, http.StatusUnauthorized, "refresh token revoked")
 		return
 	}
@@ -367,20 +792,92 @@ func (cfg *apiConfig) refreshToken(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnauthorized, "refresh token expired")
 		return
 	}
-	access_token, err := auth.MakeJWT(data.ID, cfg.Secret, time.Duration(3600)*time.Second)
+
+	new_token, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating refresh token")
+		return
+	}
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rotating refresh token")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		Token:     new_token,
+		UserID:    data.ID,
+		ExpiresAt: time.Now().AddDate(0, 0, 60),
+		FamilyID:  data.FamilyID,
+		UserAgent: r.UserAgent(),
+		Ip:        cfg.clientIP(r),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rotating refresh token")
+		return
+	}
+	rotated, err := qtx.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+		RevokedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		ReplacedBy: sql.NullString{String: new_token, Valid: true},
+		Token:      refresh_token,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rotating refresh token")
+		return
+	}
+	if rotated == 0 {
+		// Another request rotated this token between our read and write.
+		tx.Rollback()
+		cfg.revokeTokenFamily(w, r, data)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rotating refresh token")
+		return
+	}
+
+	access_token, err := cfg.makeAccessToken(data.ID, data.FamilyID, data.Role)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error creating acces token")
 		return
 	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.TokenRefresh,
+		ActorID:   data.ID,
+		SubjectID: data.ID,
+		Data:      map[string]uuid.UUID{"session_id": data.FamilyID},
+	})
 
 	resp := struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}{
-		Token: access_token,
+		Token:        access_token,
+		RefreshToken: new_token,
 	}
 	respondWithJSON(w, http.StatusOK, resp)
 }
 
+// revokeTokenFamily handles reuse of an already rotated refresh token by
+// revoking every token descended from the same login.
+func (cfg *apiConfig) revokeTokenFamily(w http.ResponseWriter, r *http.Request, data database.GetUserFromRefreshTokenRow) {
+	log.Printf("refresh token reuse detected: user %s, family %s; revoking family", data.ID, data.FamilyID)
+	err := cfg.DB.RevokeRefreshTokenFamily(r.Context(), database.RevokeRefreshTokenFamilyParams{
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		FamilyID:  data.FamilyID,
+	})
+	if err != nil {
+		log.Printf("error revoking refresh token family %s: %v", data.FamilyID, err)
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.TokenReuseDetected,
+		SubjectID: data.ID,
+		Data:      map[string]uuid.UUID{"session_id": data.FamilyID},
+	})
+	respondWithError(w, http.StatusUnauthorized, "refresh token reused, all sessions of this login were revoked")
+}
+
 func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refresh_token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -397,17 +894,28 @@ func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusInternalServerError, "error revoking token")
 		return
 	}
+	if data, err := cfg.DB.GetUserFromRefreshToken(r.Context(), refresh_token); err == nil {
+		cfg.audit(r, audit.Event{
+			Type:      audit.TokenRevoke,
+			ActorID:   data.ID,
+			SubjectID: data.ID,
+			Data:      map[string]string{"kind": "refresh_token", "session_id": data.FamilyID.String()},
+		})
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// changeUserData updates email and password. A password change signs out
+// every other session of the user. A new email only takes effect once it
+// has been confirmed through the link mailed to it.
 func (cfg *apiConfig) changeUserData(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
 	}
-	access_token, err := auth.GetBearerToken(r.Header)
+	caller, err := cfg.authorize(r, auth.ScopeProfileWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error receiving refesh token, in revoke refresh")
+		respondWithAuthError(w, err)
 		return
 	}
 	decoder := json.NewDecoder(r.Body)
@@ -417,30 +925,95 @@ func (cfg *apiConfig) changeUserData(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
-	var newUserData database.UpdateUserDataParams
-	userID, err := auth.ExtractUserIDFromJWT(access_token, cfg.Secret)
+	userID := caller.UserID
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "error parsing userID from token")
+		respondWithError(w, http.StatusUnauthorized, "user not found")
 		return
 	}
-	newUserData.ID = userID
-	newUserData.Email = param.Email
-	new_hashed_passwd, err := auth.HashPassword(param.Password)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error hashing new password")
+	emailChanged := param.Email != user.Email
+	if emailChanged {
+		if err := validateEmail(param.Email); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, err := cfg.DB.GetPasswordFromEmail(r.Context(), param.Email); err == nil {
+			respondWithError(w, http.StatusConflict, "email address is already in use")
+			return
+		}
 	}
-	newUserData.HashedPassword = new_hashed_passwd
-	newUserData.UpdatedAt = time.Now()
-	err = cfg.DB.UpdateUserData(r.Context(), newUserData)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error updating user data")
+
+	if param.Password != "" && user.HashedPassword == "" {
+		respondWithError(w, http.StatusForbidden, "account signs in through an external identity provider")
 		return
 	}
+	passwordChanged := param.Password != "" && cfg.Passwords.Check(user.HashedPassword, param.Password) != nil
+	if passwordChanged {
+		new_hashed_passwd, err := cfg.Passwords.Hash(param.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error hashing new password")
+			return
+		}
+		err = cfg.DB.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			ID:             userID,
+			HashedPassword: new_hashed_passwd,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error updating user data")
+			return
+		}
+		err = cfg.DB.RevokeOtherUserRefreshTokens(r.Context(), database.RevokeOtherUserRefreshTokensParams{
+			RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			UserID:    userID,
+			FamilyID:  caller.SessionID,
+		})
+		if err != nil {
+			log.Printf("error revoking other sessions of user %s: %v", userID, err)
+		}
+	}
+
 	resp := User{
-		ID:        userID,
-		CreatedAt: newUserData.UpdatedAt,
-		UpdatedAt: newUserData.UpdatedAt,
-		Email:     param.Email,
+		ID:         userID,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		Email:      user.Email,
+		Handle:     user.Handle.String,
+		Red:        user.IsChirpyRed,
+		IsVerified: user.VerifiedAt.Valid,
+		Role:       user.Role,
+	}
+	if passwordChanged {
+		resp.UpdatedAt = time.Now()
+	}
+	if emailChanged || passwordChanged {
+		type profile struct {
+			Email           string `json:"email,omitempty"`
+			PendingEmail    string `json:"pending_email,omitempty"`
+			PasswordChanged bool   `json:"password_changed,omitempty"`
+		}
+		after := profile{Email: user.Email, PasswordChanged: passwordChanged}
+		if emailChanged {
+			after.PendingEmail = param.Email
+		}
+		cfg.audit(r, audit.Event{
+			Type:      audit.UserUpdate,
+			ActorID:   userID,
+			SubjectID: userID,
+			Data:      audit.Diff{Before: profile{Email: user.Email}, After: after},
+		})
+	}
+	if emailChanged {
+		if err := cfg.sendEmailVerification(r.Context(), userID, param.Email); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error sending verification email")
+			return
+		}
+		cfg.sendMail(mailer.Message{
+			To:      user.Email,
+			Subject: "Your Chirpy email address is being changed",
+			Body: fmt.Sprintf("Someone asked to change the email address of your Chirpy account to %s.\n"+
+				"The change only happens once the new address is confirmed. If this wasn't you, reset your password.\n", param.Email),
+		}, userID)
+		resp.PendingEmail = param.Email
 	}
 
 	respondWithJSON(w, http.StatusOK, resp)
@@ -455,30 +1028,34 @@ func (cfg *apiConfig) deleteChirpyById(w http.ResponseWriter, r *http.Request) {
 	}
 	// get chirp
 	chirp, err := cfg.DB.GetChirpById(r.Context(), parsed_chirpID)
-	if err != nil {
+	if err != nil || chirp.DeletedAt.Valid {
 		respondWithError(w, http.StatusNotFound, "error getting chirp out of db")
 		return
 	}
 	// get user data
-	access_token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Error receiving refresh token, in revoke refresh")
-		return
-	}
-	userID, err := auth.ExtractUserIDFromJWT(access_token, cfg.Secret)
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "error parsing userID from token")
+		respondWithAuthError(w, err)
 		return
 	}
-	if userID != chirp.UserID {
+	// Moderators may delete any chirp, but only from a full login session.
+	if caller.UserID != chirp.UserID.UUID && (caller.Scopes != nil || !cfg.hasPermission(r, caller.UserID, permChirpsDeleteAny)) {
 		respondWithError(w, http.StatusForbidden, "user is not the author of the chirp, cant delete other users chirps")
 		return
 	}
-	err = cfg.DB.DeleteChripyById(r.Context(), parsed_chirpID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "error deleting chirp, not found")
+	if caller.UserID != chirp.UserID.UUID {
+		log.Printf("moderator %s deleted chirp %s of user %s", caller.UserID, chirp.ID, chirp.UserID.UUID)
+	}
+	if err := cfg.removeChirp(r.Context(), chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error deleting chirp")
 		return
 	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.ChirpDelete,
+		ActorID:   caller.UserID,
+		SubjectID: chirp.UserID.UUID,
+		Data:      audit.Diff{Before: chirpFromDB(chirp)},
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -511,13 +1088,169 @@ func (cfg *apiConfig) UpgradeUserToRed(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
+	user, err := cfg.DB.GetUserById(r.Context(), param.Data.UserId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found, couldnt upgrade")
+		return
+	}
 	err = cfg.DB.UpdateUserToRed(r.Context(), param.Data.UserId)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "user not found, couldnt upgrade")
+		return
 	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.UserUpgradeRed,
+		SubjectID: user.ID,
+		Data: audit.Diff{
+			Before: map[string]bool{"is_chirpy_red": user.IsChirpyRed},
+			After:  map[string]bool{"is_chirpy_red": true},
+		},
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.Keys.JWKS())
+}
+
+// loadKeyring builds the token keyring from the environment.
+// JWT_SIGNING_KEYS and JWT_RETIRING_KEYS are comma separated PEM files;
+// the first signing key signs new tokens. HS256 tokens signed with SECRET
+// are accepted until JWT_HS256_ACCEPT_UNTIL (RFC 3339), or forever if unset.
+func loadKeyring(secret string) (*auth.Keyring, error) {
+	var legacyUntil time.Time
+	if until := os.Getenv("JWT_HS256_ACCEPT_UNTIL"); until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWT_HS256_ACCEPT_UNTIL: %v", err)
+		}
+		legacyUntil = parsed
+	}
+	keyring := auth.NewKeyring(secret, legacyUntil)
+	for _, env := range []string{"JWT_SIGNING_KEYS", "JWT_RETIRING_KEYS"} {
+		for _, path := range strings.Split(os.Getenv(env), ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			pemData, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %v", path, err)
+			}
+			private, err := auth.ParsePrivateKeyPEM(pemData)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %v", path, err)
+			}
+			key, err := keyring.AddKey(private, env == "JWT_RETIRING_KEYS")
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %v", path, err)
+			}
+			fmt.Printf("Loaded %s key %s from %s\n", key.Algorithm, key.ID, path)
+		}
+	}
+	return keyring, nil
+}
+
+// loadMailer picks the mail backend from MAIL_BACKEND: "smtp" relays
+// through SMTP_HOST, anything else writes to the MAIL_OUTBOX_DIR outbox.
+func loadMailer() (mailer.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Chirpy <no-reply@localhost>"
+	}
+	if os.Getenv("MAIL_BACKEND") == "smtp" {
+		port := 587
+		if p := os.Getenv("SMTP_PORT"); p != "" {
+			parsed, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("parsing SMTP_PORT: %v", err)
+			}
+			port = parsed
+		}
+		return mailer.NewSMTPMailer(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	}
+	dir := os.Getenv("MAIL_OUTBOX_DIR")
+	if dir == "" {
+		dir = "outbox"
+	}
+	return mailer.NewOutboxMailer(dir, from)
+}
+
+// loadBlobStore picks the storage for uploads from BLOB_STORE: "s3" uses
+// the S3_* settings, anything else keeps files in BLOB_DIR.
+func loadBlobStore() (blob.Store, error) {
+	if os.Getenv("BLOB_STORE") == "s3" {
+		for _, env := range []string{"S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY"} {
+			if os.Getenv(env) == "" {
+				return nil, fmt.Errorf("%s must be set", env)
+			}
+		}
+		return blob.NewS3Store(os.Getenv("S3_ENDPOINT"), os.Getenv("S3_REGION"), os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_ACCESS_KEY_ID"), os.Getenv("S3_SECRET_ACCESS_KEY")), nil
+	}
+	dir := os.Getenv("BLOB_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	return blob.NewLocalStore(dir)
+}
+
+// loadPasswordHasher reads ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and
+// ARGON2_PARALLELISM on top of the default Argon2id parameters. Raising
+// them upgrades existing hashes as users log in.
+func loadPasswordHasher() (*auth.PasswordHasher, error) {
+	params := auth.DefaultArgon2idParams
+	for env, target := range map[string]*uint32{
+		"ARGON2_MEMORY_KIB": &params.Memory,
+		"ARGON2_ITERATIONS": &params.Iterations,
+	} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || parsed == 0 {
+				return nil, fmt.Errorf("parsing %s: must be a positive integer", env)
+			}
+			*target = uint32(parsed)
+		}
+	}
+	if value := os.Getenv("ARGON2_PARALLELISM"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || parsed == 0 {
+			return nil, fmt.Errorf("parsing ARGON2_PARALLELISM: must be between 1 and 255")
+		}
+		params.Parallelism = uint8(parsed)
+	}
+	return auth.NewPasswordHasher(params), nil
+}
+
+// loadDuration reads a duration such as "30m" from env, or returns
+// fallback if it is unset.
+func loadDuration(env string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("parsing %s: must be a duration like 30m", env)
+	}
+	return parsed, nil
+}
+
+// loadLength reads a positive number of characters from env, or returns
+// fallback if it isn't set.
+func loadLength(env string, fallback int) (int, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, fmt.Errorf("parsing %s: must be a positive integer", env)
+	}
+	return parsed, nil
+}
+
 func main() {
 	godotenv.Load()
 	mux := http.NewServeMux()
@@ -530,29 +1263,188 @@ func main() {
 	platform := os.Getenv("PLATFORM")
 	secret := os.Getenv("SECRET")
 	polka_api_key := os.Getenv("POLKA_KEY")
+	trust_proxy := os.Getenv("TRUST_PROXY_HEADERS") == "true"
+	require_verified := os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true"
+	base_url := os.Getenv("BASE_URL")
+	if base_url == "" {
+		base_url = "http://localhost:8080"
+	}
+	passwords, err := loadPasswordHasher()
+	if err != nil {
+		fmt.Println("Error configuring password hashing!", err)
+		os.Exit(1)
+	}
+	// LOCKOUT_STORE=postgres shares failed login counts between replicas.
+	var lockoutStore lockout.Store = lockout.NewMemoryStore()
+	if os.Getenv("LOCKOUT_STORE") == "postgres" {
+		lockoutStore = lockout.NewPostgresStore(dbQueries)
+	}
+	mail, err := loadMailer()
+	if err != nil {
+		fmt.Println("Error setting up mail delivery!", err)
+		os.Exit(1)
+	}
+	keyring, err := loadKeyring(secret)
+	if err != nil {
+		fmt.Println("Error loading signing keys!", err)
+		os.Exit(1)
+	}
+	bootstrapAdmins(context.Background(), dbQueries)
+	deletion_grace, err := loadDuration("ACCOUNT_DELETION_GRACE_PERIOD", defaultDeletionGracePeriod)
+	if err != nil {
+		fmt.Println("Error in account deletion settings!", err)
+		os.Exit(1)
+	}
+	edit_window, err := loadDuration("CHIRP_EDIT_WINDOW", defaultEditWindow)
+	if err != nil {
+		fmt.Println("Error in chirp edit settings!", err)
+		os.Exit(1)
+	}
+	red_edit_window, err := loadDuration("CHIRP_EDIT_WINDOW_RED", defaultRedEditWindow)
+	if err != nil {
+		fmt.Println("Error in chirp edit settings!", err)
+		os.Exit(1)
+	}
+	max_chirp_length, err := loadLength("CHIRP_MAX_LENGTH", defaultMaxChirpLength)
+	if err != nil {
+		fmt.Println("Error in chirp length settings!", err)
+		os.Exit(1)
+	}
+	red_max_chirp_length, err := loadLength("CHIRP_MAX_LENGTH_RED", defaultRedMaxChirpLength)
+	if err != nil {
+		fmt.Println("Error in chirp length settings!", err)
+		os.Exit(1)
+	}
+	oidc_providers, err := loadOIDCProviders(base_url)
+	if err != nil {
+		fmt.Println("Error configuring identity providers!", err)
+		os.Exit(1)
+	}
+	blobs, err := loadBlobStore()
+	if err != nil {
+		fmt.Println("Error setting up upload storage!", err)
+		os.Exit(1)
+	}
+	media_upload_ttl, err := loadDuration("MEDIA_UPLOAD_TTL", defaultMediaUploadTTL)
+	if err != nil {
+		fmt.Println("Error in media settings!", err)
+		os.Exit(1)
+	}
+	media_base_url := os.Getenv("MEDIA_BASE_URL")
+	if media_base_url == "" {
+		media_base_url = base_url + "/media/"
+	}
 
 	fs := http.FileServer(http.Dir("."))
 
 	apiCfg := apiConfig{
 		DB:            dbQueries,
+		Conn:          db,
 		PLATFORM:      platform,
 		Secret:        secret,
 		POLKA_API_KEY: polka_api_key,
-	}
+		Keys:          keyring,
+
+		TrustProxyHeaders: trust_proxy,
+		Mailer:            mail,
+		BaseURL:           base_url,
+
+		RequireVerifiedEmail: require_verified,
+		Passwords:            passwords,
+		Lockout:              lockout.NewLimiter(lockoutStore),
+		OIDCProviders:        oidc_providers,
+		Audit:                audit.NewLogger(dbQueries, db),
+		DeletionGracePeriod:  deletion_grace,
+		EditWindow:           edit_window,
+		RedEditWindow:        red_edit_window,
+		MaxChirpLength:       max_chirp_length,
+		RedMaxChirpLength:    red_max_chirp_length,
+		Blobs:                blobs,
+		MediaBaseURL:         media_base_url,
+		MediaUploadTTL:       media_upload_ttl,
+	}
+	go apiCfg.runPurger(context.Background(), purgeInterval)
+	go apiCfg.backfillEntities(context.Background())
 
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", fs)))
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.resetMetrics)
+	mux.HandleFunc("GET /admin/metrics", apiCfg.requirePermission(permAdminMetrics, apiCfg.handlerMetrics))
+	mux.HandleFunc("POST /admin/reset", apiCfg.requirePermission(permAdminReset, apiCfg.resetMetrics))
 	mux.HandleFunc("POST /api/users", apiCfg.createNewUser)
 	mux.HandleFunc("POST /api/chirps", apiCfg.postChirp)
+	mux.HandleFunc("POST /api/media", apiCfg.uploadMedia)
 	mux.HandleFunc("GET /api/chirps", apiCfg.GetAllChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getChipById)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.getThread)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/like", apiCfg.likeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.unlikeChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/reactions/{reaction}", apiCfg.addReaction)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/reactions/{reaction}", apiCfg.removeReaction)
+	mux.HandleFunc("GET /api/users/{id}/likes", apiCfg.listUserLikes)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.rechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.undoRechirp)
+	mux.HandleFunc("PUT /api/users/{id}/follow", apiCfg.followUser)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.unfollowUser)
+	mux.HandleFunc("GET /api/users/{id}/followers", apiCfg.listFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", apiCfg.listFollowing)
+	mux.HandleFunc("GET /api/timeline", apiCfg.getTimeline)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.getHashtagChirps)
+	mux.HandleFunc("GET /api/users/{handle}", apiCfg.getProfile)
+	mux.HandleFunc("PATCH /api/profile", apiCfg.updateProfile)
+	mux.HandleFunc("PUT /api/profile/avatar", apiCfg.uploadAvatar)
+	mux.HandleFunc("DELETE /api/profile/avatar", apiCfg.deleteAvatar)
+	mux.HandleFunc("PUT /api/profile/header", apiCfg.uploadHeader)
+	mux.HandleFunc("DELETE /api/profile/header", apiCfg.deleteHeader)
+	mux.HandleFunc("GET /media/{key...}", apiCfg.serveMedia)
+	mux.HandleFunc("PUT /api/users/{id}/block", apiCfg.blockUser)
+	mux.HandleFunc("DELETE /api/users/{id}/block", apiCfg.unblockUser)
+	mux.HandleFunc("GET /api/blocks", apiCfg.listBlocks)
+	mux.HandleFunc("PUT /api/users/{id}/mute", apiCfg.muteUser)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", apiCfg.unmuteUser)
+	mux.HandleFunc("GET /api/mutes", apiCfg.listMutes)
+	mux.HandleFunc("POST /api/mutes/words", apiCfg.muteWord)
+	mux.HandleFunc("GET /api/mutes/words", apiCfg.listMutedWords)
+	mux.HandleFunc("DELETE /api/mutes/words/{id}", apiCfg.unmuteWord)
 	mux.HandleFunc("POST /api/login", apiCfg.authenticateLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.refreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.revokeRefreshToken)
 	mux.HandleFunc("PUT /api/users", apiCfg.changeUserData)
+	mux.HandleFunc("DELETE /api/users", apiCfg.deleteUser)
+	mux.HandleFunc("POST /api/users/export", apiCfg.requestDataExport)
+	mux.HandleFunc("GET /api/exports/{id}", apiCfg.getDataExport)
+	mux.HandleFunc("GET /api/exports/{id}/download", apiCfg.downloadDataExport)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirpyById)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", apiCfg.editChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.listChirpRevisions)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.UpgradeUserToRed)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
+	mux.HandleFunc("GET /api/sessions", apiCfg.listSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", apiCfg.revokeSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.revokeAllSessions)
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.forgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.resetPassword)
+	mux.HandleFunc("POST /api/users/verify", apiCfg.verifyEmail)
+	mux.HandleFunc("POST /api/users/verify/resend", apiCfg.resendEmailVerification)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.completeMFALogin)
+	mux.HandleFunc("POST /api/mfa/totp/setup", apiCfg.setupTOTP)
+	mux.HandleFunc("POST /api/mfa/totp/enable", apiCfg.enableTOTP)
+	mux.HandleFunc("POST /api/mfa/totp/disable", apiCfg.disableTOTP)
+	mux.HandleFunc("POST /admin/users/{userID}/unlock", apiCfg.requirePermission(permUsersUnlock, apiCfg.unlockUser))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", apiCfg.requirePermission(permUsersManageRole, apiCfg.setUserRole))
+	mux.HandleFunc("GET /admin/audit", apiCfg.requirePermission(permAuditRead, apiCfg.listAuditEvents))
+	mux.HandleFunc("GET /admin/audit/verify", apiCfg.requirePermission(permAuditRead, apiCfg.verifyAuditLog))
+	mux.HandleFunc("POST /api/tokens", apiCfg.createPersonalAccessToken)
+	mux.HandleFunc("GET /api/tokens", apiCfg.listPersonalAccessTokens)
+	mux.HandleFunc("DELETE /api/tokens/{id}", apiCfg.revokePersonalAccessToken)
+	mux.HandleFunc("POST /api/oauth/clients", apiCfg.registerOAuthClient)
+	mux.HandleFunc("GET /api/oauth/clients", apiCfg.listOAuthClients)
+	mux.HandleFunc("DELETE /api/oauth/clients/{id}", apiCfg.deleteOAuthClient)
+	mux.HandleFunc("GET /oauth/authorize", apiCfg.oauthAuthorize)
+	mux.HandleFunc("POST /oauth/authorize", apiCfg.oauthAuthorizeSubmit)
+	mux.HandleFunc("POST /oauth/token", apiCfg.oauthToken)
+	mux.HandleFunc("POST /oauth/revoke", apiCfg.oauthRevoke)
+	mux.HandleFunc("POST /oauth/introspect", apiCfg.oauthIntrospect)
+	mux.HandleFunc("GET /auth/oidc/{provider}/login", apiCfg.oidcLogin)
+	mux.HandleFunc("GET /auth/oidc/{provider}/callback", apiCfg.oidcCallback)
 
 	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/images"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaBytes    = 15 << 20
+	maxMediaPerChirp = 4
+	maxAltTextLength = 1000
+	// defaultMediaUploadTTL is how long uploads may wait to be attached
+	// to a chirp before the purger deletes them.
+	defaultMediaUploadTTL = 24 * time.Hour
+)
+
+const (
+	mediaKindImage = "image"
+	// mediaKindGIF is an animated GIF; a chirp can carry only one.
+	mediaKindGIF = "gif"
+)
+
+type Media struct {
+	ID              uuid.UUID `json:"id"`
+	Type            string    `json:"type"`
+	URL             string    `json:"url"`
+	Width           int32     `json:"width"`
+	Height          int32     `json:"height"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  int32     `json:"thumbnail_width"`
+	ThumbnailHeight int32     `json:"thumbnail_height"`
+	// Blurhash is a placeholder to show while the image loads, see
+	// https://blurha.sh.
+	Blurhash string `json:"blurhash"`
+	AltText  string `json:"alt_text"`
+}
+
+func (cfg *apiConfig) mediaFromDB(media database.MediaAttachment) Media {
+	return Media{
+		ID:              media.ID,
+		Type:            media.Kind,
+		URL:             cfg.MediaBaseURL + media.Key,
+		Width:           media.Width,
+		Height:          media.Height,
+		ThumbnailURL:    cfg.MediaBaseURL + media.ThumbnailKey,
+		ThumbnailWidth:  media.ThumbnailWidth,
+		ThumbnailHeight: media.ThumbnailHeight,
+		Blurhash:        media.Blurhash,
+		AltText:         media.AltText,
+	}
+}
+
+// uploadMedia stores the "file" of a multipart form, with an optional
+// "alt_text", for a chirp posted later with its ID in media_ids. Uploads
+// nobody attaches are deleted after MediaUploadTTL.
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaBytes+(1<<20))
+	file, _, err := r.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "media must be at most 15 MB")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "expected a multipart form with a file")
+		return
+	}
+	defer file.Close()
+	altText := r.FormValue("alt_text")
+	if !utf8.ValidString(altText) || utf8.RuneCountInString(altText) > maxAltTextLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("alt text must be at most %d characters", maxAltTextLength))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error reading file")
+		return
+	}
+	if len(data) > maxMediaBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "media must be at most 15 MB")
+		return
+	}
+
+	att, err := images.ProcessAttachment(data)
+	if errors.Is(err, images.ErrUnsupported) {
+		respondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if errors.Is(err, images.ErrTooLarge) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error processing media")
+		return
+	}
+	id := uuid.New()
+	key, err := cfg.putMedia(r.Context(), id, att.Image)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error storing media")
+		return
+	}
+	thumbnailKey, err := cfg.putMedia(r.Context(), id, att.Thumbnail)
+	if err != nil {
+		cfg.deleteBlob(r.Context(), key)
+		respondWithError(w, http.StatusInternalServerError, "error storing media")
+		return
+	}
+	kind := mediaKindImage
+	if att.Animated {
+		kind = mediaKindGIF
+	}
+	media, err := cfg.DB.CreateMediaAttachment(r.Context(), database.CreateMediaAttachmentParams{
+		ID:              id,
+		UserID:          uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		Kind:            kind,
+		Key:             key,
+		Width:           int32(att.Image.Width),
+		Height:          int32(att.Image.Height),
+		ThumbnailKey:    thumbnailKey,
+		ThumbnailWidth:  int32(att.Thumbnail.Width),
+		ThumbnailHeight: int32(att.Thumbnail.Height),
+		Blurhash:        att.Blurhash,
+		AltText:         altText,
+	})
+	if err != nil {
+		cfg.deleteBlob(r.Context(), key)
+		cfg.deleteBlob(r.Context(), thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "error storing media")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, cfg.mediaFromDB(media))
+}
+
+// putMedia stores a processed image below the ID of its upload, so
+// deleting one upload never touches the files of another.
+func (cfg *apiConfig) putMedia(ctx context.Context, id uuid.UUID, image images.Result) (string, error) {
+	sum := sha256.Sum256(image.Data)
+	key := fmt.Sprintf("media/%s/%s%s", id, hex.EncodeToString(sum[:16]), image.Ext)
+	if err := cfg.Blobs.Put(ctx, key, image.ContentType, image.Data); err != nil {
+		log.Printf("error storing %s: %v", key, err)
+		return "", err
+	}
+	return key, nil
+}
+
+// checkMedia makes sure the uploads in ids can be attached to a new chirp
+// of the user: up to four images, or a single GIF. Attaching them in the
+// transaction creating the chirp checks again that they're still free.
+func (cfg *apiConfig) checkMedia(w http.ResponseWriter, r *http.Request, userID uuid.UUID, ids []uuid.UUID) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	if len(ids) > maxMediaPerChirp {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("chirps can have at most %d images", maxMediaPerChirp))
+		return false
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			respondWithError(w, http.StatusBadRequest, "media_ids contains duplicates")
+			return false
+		}
+		seen[id] = true
+	}
+	media, err := cfg.DB.GetMediaAttachmentsByIds(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving media")
+		return false
+	}
+	if len(media) != len(ids) {
+		respondWithError(w, http.StatusBadRequest, "media not found")
+		return false
+	}
+	for _, m := range media {
+		if m.UserID.UUID != userID || !m.UserID.Valid {
+			respondWithError(w, http.StatusBadRequest, "media not found")
+			return false
+		}
+		if m.AttachedAt.Valid {
+			respondWithError(w, http.StatusConflict, "media is already attached to a chirp")
+			return false
+		}
+		if m.Kind == mediaKindGIF && len(ids) > 1 {
+			respondWithError(w, http.StatusBadRequest, "a GIF can't be combined with other media")
+			return false
+		}
+	}
+	return true
+}
+
+// loadMedia adds the attachments to chirps.
+func (cfg *apiConfig) loadMedia(ctx context.Context, chirps []Chirp) error {
+	index := make(map[uuid.UUID][]int, len(chirps))
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for i, chirp := range chirps {
+		if chirp.Deleted {
+			continue
+		}
+		if _, ok := index[chirp.ID]; !ok {
+			ids = append(ids, chirp.ID)
+		}
+		index[chirp.ID] = append(index[chirp.ID], i)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	media, err := cfg.DB.ListChirpMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, m := range media {
+		for _, i := range index[m.ChirpID.UUID] {
+			chirps[i].Media = append(chirps[i].Media, cfg.mediaFromDB(m))
+		}
+	}
+	return nil
+}
+
+// purgeMedia deletes uploads that were never attached within
+// MediaUploadTTL, and those left behind by deleted chirps and accounts.
+func (cfg *apiConfig) purgeMedia(ctx context.Context) {
+	rows, err := cfg.DB.DeleteOrphanedMedia(ctx, time.Now().Add(-cfg.MediaUploadTTL))
+	if err != nil {
+		log.Printf("error deleting unattached media: %v", err)
+		return
+	}
+	for _, row := range rows {
+		cfg.deleteBlob(ctx, row.Key)
+		cfg.deleteBlob(ctx, row.ThumbnailKey)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+	totpIssuer        = "Chirpy"
+)
+
+// startMFAChallenge answers the first login step of a user with TOTP
+// enabled. The returned token proves the password was correct and is
+// exchanged at POST /api/login/mfa together with a code.
+func (cfg *apiConfig) startMFAChallenge(w http.ResponseWriter, user database.User) {
+	claims := auth.NewClaims(user.ID, mfaChallengeTTL)
+	claims.TokenUse = auth.TokenUseMFA
+	mfa_token, err := cfg.Keys.Sign(claims)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating token string")
+		return
+	}
+	resp := struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}{
+		MFARequired: true,
+		MFAToken:    mfa_token,
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused
+// recovery code.
+func (cfg *apiConfig) checkSecondFactor(r *http.Request, user database.User, code, recoveryCode string) bool {
+	if recoveryCode != "" {
+		used, err := cfg.DB.UseRecoveryCode(r.Context(), database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(recoveryCode)),
+		})
+		return err == nil && used == 1
+	}
+	if !user.TotpSecret.Valid {
+		return false
+	}
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now(), user.TotpLastStep)
+	if !ok {
+		return false
+	}
+	// Only the request that advances the step may use the code.
+	advanced, err := cfg.DB.AdvanceUserTOTPStep(r.Context(), database.AdvanceUserTOTPStepParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	return err == nil && advanced == 1
+}
+
+func (cfg *apiConfig) completeMFALogin(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err := decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	claims, err := cfg.Keys.ParseClaimsFor(param.MFAToken, auth.TokenUseMFA)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "MFA token invalid or expired")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil || !user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "MFA token invalid or expired")
+		return
+	}
+	if cfg.checkLockout(w, r, user.Email) {
+		return
+	}
+	if !cfg.checkSecondFactor(r, user, param.Code, param.RecoveryCode) {
+		cfg.recordLoginFailure(r, user.Email)
+		respondWithError(w, http.StatusUnauthorized, "Wrong code!")
+		return
+	}
+	cfg.recordLoginSuccess(r, user, "mfa")
+	cfg.issueLogin(w, r, user)
+}
+
+// setupTOTP creates a new secret for the user. It only becomes active once
+// a code generated from it is confirmed with enableTOTP.
+func (cfg *apiConfig) setupTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating TOTP secret")
+		return
+	}
+	updated, err := cfg.DB.SetUserTOTPSecret(r.Context(), database.SetUserTOTPSecretParams{
+		ID:         userID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error storing TOTP secret")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	resp := struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) enableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "start the setup first")
+		return
+	}
+	step, ok := auth.ValidateTOTP(user.TotpSecret.String, param.Code, time.Now(), 0)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Wrong code!")
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating recovery codes")
+		return
+	}
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error enabling two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if err := replaceRecoveryCodes(r, qtx, userID, codes); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error storing recovery codes")
+		return
+	}
+	err = qtx.EnableUserTOTP(r.Context(), database.EnableUserTOTPParams{
+		ID:           userID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error enabling two-factor authentication")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error enabling two-factor authentication")
+		return
+	}
+
+	resp := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func replaceRecoveryCodes(r *http.Request, qtx *database.Queries, userID uuid.UUID, codes []string) error {
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		err := qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disableTOTP turns two-factor authentication off. It needs the password
+// and a second factor, so a stolen access token alone can't do it.
+func (cfg *apiConfig) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	userID, _ := claims.UserID()
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is not enabled")
+		return
+	}
+	if err := cfg.Passwords.Check(user.HashedPassword, param.Password); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Wrong Password!")
+		return
+	}
+	if !cfg.checkSecondFactor(r, user, param.Code, param.RecoveryCode) {
+		respondWithError(w, http.StatusUnauthorized, "Wrong code!")
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error disabling two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if err := qtx.DisableUserTOTP(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error disabling two-factor authentication")
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error disabling two-factor authentication")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error disabling two-factor authentication")
+		return
+	}
+	log.Printf("two-factor authentication disabled for user %s", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
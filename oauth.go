@@ -0,0 +1,694 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/oauth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	oauthCodeTTL         = time.Minute
+	oauthAccessTokenTTL  = time.Hour
+	oauthRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var scopeDescriptions = map[string]string{
+	auth.ScopeChirpsRead:   "Read chirps on your behalf",
+	auth.ScopeChirpsWrite:  "Post and delete chirps as you",
+	auth.ScopeProfileWrite: "Change your email address and password",
+	auth.ScopeFollowsWrite: "Follow and unfollow accounts as you",
+	auth.ScopeBlocksWrite:  "Block and mute accounts and words for you",
+	auth.ScopeProfileEdit:  "Change your handle, display name, bio, location, website and profile images",
+}
+
+var consentTemplate = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Authorize {{.ClientName}} - Chirpy</title></head>
+<body>
+<h1>{{.ClientName}} wants to access your Chirpy account</h1>
+{{if .Error}}<p style="color: #b00">{{.Error}}</p>{{end}}
+<p>It will be able to:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="code">
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="S256">
+<p><label>Email <input type="email" name="email" autocomplete="username" required></label></p>
+<p><label>Password <input type="password" name="password" autocomplete="current-password" required></label></p>
+<p><label>Two-factor code (if enabled) <input type="text" name="code" inputmode="numeric" autocomplete="one-time-code"></label></p>
+<button type="submit" name="decision" value="allow">Allow</button>
+<button type="submit" name="decision" value="deny" formnovalidate>Deny</button>
+</form>
+</body>
+</html>
+`))
+
+var oauthErrorTemplate = template.Must(template.New("oauth-error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Authorization failed - Chirpy</title></head>
+<body>
+<h1>Authorization failed</h1>
+<p>{{.}}</p>
+</body>
+</html>
+`))
+
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	Secret       string    `json:"client_secret,omitempty"`
+}
+
+func oauthClientFromDB(client database.OauthClient) OAuthClient {
+	return OAuthClient{
+		ID:           client.ID,
+		CreatedAt:    client.CreatedAt,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		Confidential: client.SecretHash.Valid,
+	}
+}
+
+// authorizeRequest is a validated request to /oauth/authorize.
+type authorizeRequest struct {
+	Client        database.OauthClient
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+}
+
+// parseAuthorizeRequest validates the parameters of an authorization
+// request. A bad client or redirect URI is returned as redirectErr=false:
+// the user must see an error page rather than be sent to an unverified URI.
+func (cfg *apiConfig) parseAuthorizeRequest(r *http.Request) (req authorizeRequest, oerr *oauth.Error, redirectErr bool) {
+	clientID, err := uuid.Parse(r.Form.Get("client_id"))
+	if err != nil {
+		return req, oauth.NewError(oauth.ErrInvalidClient, "unknown client"), false
+	}
+	req.Client, err = cfg.DB.GetOAuthClient(r.Context(), clientID)
+	if err != nil {
+		return req, oauth.NewError(oauth.ErrInvalidClient, "unknown client"), false
+	}
+	req.RedirectURI = r.Form.Get("redirect_uri")
+	if !oauth.MatchRedirectURI(req.Client.RedirectUris, req.RedirectURI) {
+		return req, oauth.NewError(oauth.ErrInvalidRequest, "redirect_uri is not registered for this client"), false
+	}
+	req.State = r.Form.Get("state")
+
+	if r.Form.Get("response_type") != "code" {
+		return req, oauth.NewError(oauth.ErrUnsupportedResponseType, "only the code response type is supported"), true
+	}
+	req.CodeChallenge = r.Form.Get("code_challenge")
+	if r.Form.Get("code_challenge_method") != "S256" || !oauth.ValidCodeVerifier(req.CodeChallenge) {
+		return req, oauth.NewError(oauth.ErrInvalidRequest, "a PKCE code_challenge with method S256 is required"), true
+	}
+	req.Scopes = oauth.ParseScope(r.Form.Get("scope"))
+	if len(req.Scopes) == 0 {
+		req.Scopes = req.Client.Scopes
+	}
+	if !oauth.Subset(req.Scopes, req.Client.Scopes) {
+		return req, oauth.NewError(oauth.ErrInvalidScope, "scope exceeds what the client may request"), true
+	}
+	return req, nil, false
+}
+
+func (cfg *apiConfig) renderConsent(w http.ResponseWriter, code int, req authorizeRequest, message string) {
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, scopeDescriptions[scope])
+	}
+	// The consent page takes credentials, so it must not be framed.
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	err := consentTemplate.Execute(w, map[string]interface{}{
+		"ClientName":    req.Client.Name,
+		"ClientID":      req.Client.ID,
+		"RedirectURI":   req.RedirectURI,
+		"Scope":         oauth.FormatScope(req.Scopes),
+		"Scopes":        scopes,
+		"State":         req.State,
+		"CodeChallenge": req.CodeChallenge,
+		"Error":         message,
+	})
+	if err != nil {
+		log.Printf("error rendering consent page: %v", err)
+	}
+}
+
+func renderOAuthError(w http.ResponseWriter, oerr *oauth.Error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	oauthErrorTemplate.Execute(w, oerr.Description)
+}
+
+func redirectOAuth(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	location, err := oauth.AppendQuery(redirectURI, params)
+	if err != nil {
+		renderOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid redirect_uri"))
+		return
+	}
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+func (cfg *apiConfig) redirectOAuthError(w http.ResponseWriter, r *http.Request, req authorizeRequest, oerr *oauth.Error) {
+	params := url.Values{"error": {oerr.Code}, "iss": {cfg.BaseURL}}
+	if oerr.Description != "" {
+		params.Set("error_description", oerr.Description)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirectOAuth(w, r, req.RedirectURI, params)
+}
+
+// oauthAuthorize shows the consent page of the authorization code flow.
+func (cfg *apiConfig) oauthAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid request"))
+		return
+	}
+	req, oerr, redirectErr := cfg.parseAuthorizeRequest(r)
+	if oerr != nil {
+		if redirectErr {
+			cfg.redirectOAuthError(w, r, req, oerr)
+		} else {
+			renderOAuthError(w, oerr)
+		}
+		return
+	}
+	cfg.renderConsent(w, http.StatusOK, req, "")
+}
+
+// oauthAuthorizeSubmit handles the consent form. The user signs in with
+// their password (and second factor) right on the form, so no Chirpy
+// session cookie is needed, and then approves or denies the client.
+func (cfg *apiConfig) oauthAuthorizeSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid request"))
+		return
+	}
+	req, oerr, redirectErr := cfg.parseAuthorizeRequest(r)
+	if oerr != nil {
+		if redirectErr {
+			cfg.redirectOAuthError(w, r, req, oerr)
+		} else {
+			renderOAuthError(w, oerr)
+		}
+		return
+	}
+	if r.PostForm.Get("decision") != "allow" {
+		cfg.redirectOAuthError(w, r, req, oauth.NewError(oauth.ErrAccessDenied, "the user denied the request"))
+		return
+	}
+
+	email := r.PostForm.Get("email")
+	wait, err := cfg.Lockout.Check(r.Context(), email, cfg.clientIP(r))
+	if err != nil {
+		log.Printf("error checking login lockout: %v", err)
+	} else if wait > 0 {
+		cfg.renderConsent(w, http.StatusTooManyRequests, req, "Too many failed logins, try again later.")
+		return
+	}
+	user, err := cfg.DB.GetPasswordFromEmail(r.Context(), email)
+	if err == nil {
+		err = cfg.Passwords.Check(user.HashedPassword, r.PostForm.Get("password"))
+	}
+	if err == nil && user.TotpEnabledAt.Valid && !cfg.checkSecondFactor(r, user, r.PostForm.Get("code"), "") {
+		err = errors.New("wrong second factor")
+	}
+	if err != nil {
+		cfg.recordLoginFailure(r, email)
+		cfg.renderConsent(w, http.StatusUnauthorized, req, "Wrong email, password or code.")
+		return
+	}
+	if cfg.Passwords.NeedsRehash(user.HashedPassword) {
+		cfg.rehashPassword(r, user.ID, r.PostForm.Get("password"))
+	}
+	cfg.recordLoginSuccess(r, user, "oauth_consent")
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		cfg.redirectOAuthError(w, r, req, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	err = cfg.DB.CreateOAuthAuthorizationCode(r.Context(), database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      req.Client.ID,
+		UserID:        user.ID,
+		RedirectUri:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(oauthCodeTTL),
+	})
+	if err != nil {
+		cfg.redirectOAuthError(w, r, req, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	params := url.Values{"code": {code}, "iss": {cfg.BaseURL}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirectOAuth(w, r, req.RedirectURI, params)
+}
+
+func respondWithOAuthError(w http.ResponseWriter, oerr *oauth.Error) {
+	code := http.StatusBadRequest
+	if oerr.Code == oauth.ErrInvalidClient {
+		code = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="chirpy"`)
+	} else if oerr.Code == oauth.ErrServerError {
+		code = http.StatusInternalServerError
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, code, oerr)
+}
+
+// authenticateClient checks the client credentials of a token, revocation
+// or introspection request. Confidential clients must present their
+// secret; public clients only identify themselves.
+func (cfg *apiConfig) authenticateClient(r *http.Request) (database.OauthClient, *oauth.Error) {
+	id, secret, err := oauth.ClientCredentials(r)
+	if err != nil {
+		return database.OauthClient{}, oauth.NewError(oauth.ErrInvalidClient, err.Error())
+	}
+	clientID, err := uuid.Parse(id)
+	if err != nil {
+		return database.OauthClient{}, oauth.NewError(oauth.ErrInvalidClient, "unknown client")
+	}
+	client, err := cfg.DB.GetOAuthClient(r.Context(), clientID)
+	if err != nil {
+		return database.OauthClient{}, oauth.NewError(oauth.ErrInvalidClient, "unknown client")
+	}
+	if client.SecretHash.Valid {
+		if subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash.String)) != 1 {
+			return database.OauthClient{}, oauth.NewError(oauth.ErrInvalidClient, "client authentication failed")
+		}
+	} else if secret != "" {
+		return database.OauthClient{}, oauth.NewError(oauth.ErrInvalidClient, "public clients have no secret")
+	}
+	return client, nil
+}
+
+// oauthToken is the token endpoint. It supports the authorization_code
+// grant with PKCE and rotating refresh tokens.
+func (cfg *apiConfig) oauthToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid form body"))
+		return
+	}
+	client, oerr := cfg.authenticateClient(r)
+	if oerr != nil {
+		respondWithOAuthError(w, oerr)
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.exchangeAuthorizationCode(w, r, client)
+	case "refresh_token":
+		cfg.exchangeOAuthRefreshToken(w, r, client)
+	default:
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrUnsupportedGrantType, ""))
+	}
+}
+
+func (cfg *apiConfig) exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	grant, err := cfg.DB.ConsumeOAuthAuthorizationCode(r.Context(), auth.HashToken(r.PostForm.Get("code")))
+	if err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "authorization code is invalid, expired or used"))
+		return
+	}
+	if grant.ClientID != client.ID || grant.RedirectUri != r.PostForm.Get("redirect_uri") {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "authorization code was issued to another client or redirect_uri"))
+		return
+	}
+	if !oauth.VerifyPKCE(r.PostForm.Get("code_verifier"), grant.CodeChallenge) {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "code_verifier does not match the code_challenge"))
+		return
+	}
+	cfg.issueOAuthTokens(w, r, client.ID, grant.UserID, grant.Scopes)
+}
+
+// exchangeOAuthRefreshToken rotates a refresh token. Presenting a token
+// that was already rotated revokes every refresh token of the grant.
+func (cfg *apiConfig) exchangeOAuthRefreshToken(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	token_hash := auth.HashToken(r.PostForm.Get("refresh_token"))
+	refresh_token, err := cfg.DB.GetOAuthRefreshToken(r.Context(), token_hash)
+	if err != nil || refresh_token.ClientID != client.ID {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "refresh token is invalid"))
+		return
+	}
+	if time.Now().After(refresh_token.ExpiresAt) {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "refresh token has expired"))
+		return
+	}
+	scopes := refresh_token.Scopes
+	if requested := oauth.ParseScope(r.PostForm.Get("scope")); len(requested) > 0 {
+		if !oauth.Subset(requested, scopes) {
+			respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidScope, "scope exceeds the original grant"))
+			return
+		}
+		scopes = requested
+	}
+	rotated := int64(0)
+	if !refresh_token.RevokedAt.Valid {
+		rotated, err = cfg.DB.RotateOAuthRefreshToken(r.Context(), token_hash)
+		if err != nil {
+			respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+			return
+		}
+	}
+	if rotated == 0 {
+		log.Printf("OAuth refresh token reuse for client %s and user %s, revoking grant", client.ID, refresh_token.UserID)
+		cfg.audit(r, audit.Event{
+			Type:      audit.TokenReuseDetected,
+			SubjectID: refresh_token.UserID,
+			Data:      map[string]string{"kind": "oauth_grant", "client_id": client.ID.String()},
+		})
+		err = cfg.DB.RevokeOAuthGrant(r.Context(), database.RevokeOAuthGrantParams{
+			ClientID: client.ID,
+			UserID:   refresh_token.UserID,
+		})
+		if err != nil {
+			log.Printf("error revoking OAuth grant: %v", err)
+		}
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidGrant, "refresh token is invalid"))
+		return
+	}
+	cfg.issueOAuthTokens(w, r, client.ID, refresh_token.UserID, scopes)
+}
+
+func (cfg *apiConfig) issueOAuthTokens(w http.ResponseWriter, r *http.Request, clientID, userID uuid.UUID, scopes []string) {
+	claims := auth.NewClaims(userID, oauthAccessTokenTTL)
+	claims.ID = uuid.NewString()
+	claims.ClientID = clientID.String()
+	claims.Scope = oauth.FormatScope(scopes)
+	access_token, err := cfg.Keys.Sign(claims)
+	if err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	refresh_token, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	err = cfg.DB.CreateOAuthRefreshToken(r.Context(), database.CreateOAuthRefreshTokenParams{
+		TokenHash: auth.HashToken(refresh_token),
+		ClientID:  clientID,
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(oauthRefreshTokenTTL),
+	})
+	if err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+		return
+	}
+	resp := struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}{
+		AccessToken:  access_token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenTTL.Seconds()),
+		RefreshToken: refresh_token,
+		Scope:        claims.Scope,
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// checkOAuthTokenRevoked rejects OAuth access tokens revoked before expiry.
+func (cfg *apiConfig) checkOAuthTokenRevoked(r *http.Request, claims auth.Claims) error {
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return errors.New("OAuth access token has no valid jti")
+	}
+	revoked, err := cfg.DB.IsOAuthAccessTokenRevoked(r.Context(), jti)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errors.New("OAuth access token has been revoked")
+	}
+	return nil
+}
+
+// oauthRevoke implements RFC 7009. Revoking a refresh token ends the whole
+// grant; access tokens are put on a deny list until they expire. Unknown
+// tokens are not an error.
+func (cfg *apiConfig) oauthRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid form body"))
+		return
+	}
+	client, oerr := cfg.authenticateClient(r)
+	if oerr != nil {
+		respondWithOAuthError(w, oerr)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if refresh_token, err := cfg.DB.GetOAuthRefreshToken(r.Context(), auth.HashToken(token)); err == nil {
+		if refresh_token.ClientID == client.ID {
+			err = cfg.DB.RevokeOAuthGrant(r.Context(), database.RevokeOAuthGrantParams{
+				ClientID: client.ID,
+				UserID:   refresh_token.UserID,
+			})
+			if err != nil {
+				respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+				return
+			}
+			cfg.audit(r, audit.Event{
+				Type:      audit.TokenRevoke,
+				SubjectID: refresh_token.UserID,
+				Data:      map[string]string{"kind": "oauth_grant", "client_id": client.ID.String()},
+			})
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	claims, err := cfg.Keys.ParseClaims(token)
+	if err == nil && claims.ClientID == client.ID.String() {
+		jti, err := uuid.Parse(claims.ID)
+		if err == nil {
+			err = cfg.DB.RevokeOAuthAccessToken(r.Context(), database.RevokeOAuthAccessTokenParams{
+				Jti:       jti,
+				ExpiresAt: claims.ExpiresAt.Time,
+			})
+			if err != nil {
+				respondWithOAuthError(w, oauth.NewError(oauth.ErrServerError, ""))
+				return
+			}
+		}
+		if err := cfg.DB.DeleteExpiredOAuthTokens(r.Context()); err != nil {
+			log.Printf("error pruning revoked OAuth access tokens: %v", err)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// oauthIntrospect implements RFC 7662. A client may only introspect the
+// tokens issued to it; anything else is reported as inactive.
+func (cfg *apiConfig) oauthIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, oauth.NewError(oauth.ErrInvalidRequest, "invalid form body"))
+		return
+	}
+	client, oerr := cfg.authenticateClient(r)
+	if oerr != nil {
+		respondWithOAuthError(w, oerr)
+		return
+	}
+	type introspection struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Subject   string `json:"sub,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		ExpiresAt int64  `json:"exp,omitempty"`
+		IssuedAt  int64  `json:"iat,omitempty"`
+		JTI       string `json:"jti,omitempty"`
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	token := r.PostForm.Get("token")
+
+	if refresh_token, err := cfg.DB.GetOAuthRefreshToken(r.Context(), auth.HashToken(token)); err == nil {
+		if refresh_token.ClientID != client.ID || refresh_token.RevokedAt.Valid || time.Now().After(refresh_token.ExpiresAt) {
+			respondWithJSON(w, http.StatusOK, introspection{})
+			return
+		}
+		respondWithJSON(w, http.StatusOK, introspection{
+			Active:    true,
+			Scope:     oauth.FormatScope(refresh_token.Scopes),
+			ClientID:  client.ID.String(),
+			Subject:   refresh_token.UserID.String(),
+			TokenType: "refresh_token",
+			ExpiresAt: refresh_token.ExpiresAt.Unix(),
+			IssuedAt:  refresh_token.CreatedAt.Unix(),
+		})
+		return
+	}
+	claims, err := cfg.Keys.ParseClaims(token)
+	if err != nil || claims.ClientID != client.ID.String() || cfg.checkOAuthTokenRevoked(r, claims) != nil {
+		respondWithJSON(w, http.StatusOK, introspection{})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		TokenType: "Bearer",
+		ExpiresAt: numericDate(claims.ExpiresAt),
+		IssuedAt:  numericDate(claims.IssuedAt),
+		JTI:       claims.ID,
+	})
+}
+
+func numericDate(date *jwt.NumericDate) int64 {
+	if date == nil {
+		return 0
+	}
+	return date.Unix()
+}
+
+// registerOAuthClient registers a third-party app owned by the caller.
+// Confidential clients get a secret, shown only in this response.
+func (cfg *apiConfig) registerOAuthClient(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Confidential bool     `json:"confidential"`
+	}
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if param.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(param.RedirectURIs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "at least one redirect URI is required")
+		return
+	}
+	for _, uri := range param.RedirectURIs {
+		if err := oauth.ValidateRedirectURI(uri); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if err := auth.ValidateScopes(param.Scopes); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	secret := ""
+	secret_hash := sql.NullString{}
+	if param.Confidential {
+		secret, err = auth.MakeRefreshToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error generating client secret")
+			return
+		}
+		secret_hash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+	userID, _ := claims.UserID()
+	client, err := cfg.DB.CreateOAuthClient(r.Context(), database.CreateOAuthClientParams{
+		OwnerID:      userID,
+		Name:         param.Name,
+		SecretHash:   secret_hash,
+		RedirectUris: param.RedirectURIs,
+		Scopes:       param.Scopes,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error registering client")
+		return
+	}
+	resp := oauthClientFromDB(client)
+	resp.Secret = secret
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) listOAuthClients(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	rows, err := cfg.DB.ListOAuthClients(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving clients")
+		return
+	}
+	clients := []OAuthClient{}
+	for _, row := range rows {
+		clients = append(clients, oauthClientFromDB(row))
+	}
+	respondWithJSON(w, http.StatusOK, clients)
+}
+
+func (cfg *apiConfig) deleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	userID, _ := claims.UserID()
+	deleted, err := cfg.DB.DeleteOAuthClient(r.Context(), database.DeleteOAuthClientParams{
+		ID:      id,
+		OwnerID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error deleting client")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "client not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
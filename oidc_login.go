@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/oauth"
+	"github.com/LucaFe1337/Chipry/internal/oidc"
+	"github.com/google/uuid"
+)
+
+const (
+	oidcStateCookie = "chirpy_oidc"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+var errIdentityEmailTaken = errors.New("an account with this email address already exists")
+
+// oidcStateClaims travel in a signed cookie from the redirect to the
+// provider until the callback, binding the callback to this browser.
+type oidcStateClaims struct {
+	auth.Claims
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS, a comma separated list of
+// provider names, and for each name OIDC_<NAME>_ISSUER, _CLIENT_ID,
+// _CLIENT_SECRET, optional _SCOPES and _TRUST_EMAIL.
+func loadOIDCProviders(baseURL string) (map[string]*oidc.Provider, error) {
+	providers := map[string]*oidc.Provider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  fmt.Sprintf("%s/auth/oidc/%s/callback", baseURL, name),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+			TrustEmail:   os.Getenv(prefix+"TRUST_EMAIL") == "true",
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			return nil, fmt.Errorf("provider %s needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		providers[name] = oidc.NewProvider(config, nil)
+	}
+	return providers, nil
+}
+
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// oidcLogin redirects the browser to the provider's sign-in page.
+func (cfg *apiConfig) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.OIDCProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+	claims := oidcStateClaims{Provider: provider.Name}
+	claims.Claims = auth.NewClaims(uuid.Nil, oidcStateTTL)
+	claims.TokenUse = auth.TokenUseOIDCState
+	var err error
+	for _, field := range []*string{&claims.State, &claims.Nonce, &claims.Verifier} {
+		if *field, err = randomURLString(32); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error starting login")
+			return
+		}
+	}
+	cookie, err := cfg.Keys.Sign(claims)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error starting login")
+		return
+	}
+	authURL, err := provider.AuthCodeURL(r.Context(), claims.State, claims.Nonce, oauth.S256Challenge(claims.Verifier))
+	if err != nil {
+		log.Printf("error contacting identity provider %s: %v", provider.Name, err)
+		respondWithError(w, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    cookie,
+		Path:     "/auth/oidc/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(cfg.BaseURL, "https://"),
+		// Lax lets the cookie come along on the provider's top-level
+		// redirect back to us.
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// oidcCallback completes an external login and signs the linked user in.
+func (cfg *apiConfig) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.OIDCProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "unknown identity provider")
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "login session missing or expired")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc/", MaxAge: -1})
+	var claims oidcStateClaims
+	if err := cfg.Keys.Parse(cookie.Value, &claims); err != nil || claims.TokenUse != auth.TokenUseOIDCState {
+		respondWithError(w, http.StatusBadRequest, "login session missing or expired")
+		return
+	}
+	query := r.URL.Query()
+	if claims.Provider != provider.Name || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(claims.State)) != 1 {
+		respondWithError(w, http.StatusBadRequest, "login state does not match")
+		return
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		respondWithError(w, http.StatusUnauthorized, "identity provider refused the login: "+errCode)
+		return
+	}
+
+	raw, err := provider.Exchange(r.Context(), query.Get("code"), claims.Verifier)
+	if err != nil {
+		log.Printf("error redeeming code from %s: %v", provider.Name, err)
+		respondWithError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+	id_token, err := provider.VerifyIDToken(r.Context(), raw, claims.Nonce)
+	if err != nil {
+		log.Printf("invalid ID token from %s: %v", provider.Name, err)
+		respondWithError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+	user, err := cfg.userForIdentity(r.Context(), provider, id_token)
+	if errors.Is(err, errIdentityEmailTaken) {
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("error linking %s identity %s: %v", provider.Name, id_token.Subject, err)
+		respondWithError(w, http.StatusInternalServerError, "error signing in")
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		cfg.startMFAChallenge(w, user)
+		return
+	}
+	cfg.recordLoginSuccess(r, user, "oidc:"+provider.Name)
+	cfg.issueLogin(w, r, user)
+}
+
+// userForIdentity returns the user linked to an external identity. On
+// first login it links an existing account by verified email if the
+// provider is trusted to vouch for emails, and otherwise creates a new
+// account without a password.
+func (cfg *apiConfig) userForIdentity(ctx context.Context, provider *oidc.Provider, id_token oidc.IDToken) (database.User, error) {
+	user, err := cfg.DB.GetUserByIdentity(ctx, database.GetUserByIdentityParams{
+		Provider: provider.Name,
+		Subject:  id_token.Subject,
+	})
+	if err == nil {
+		return user, nil
+	}
+	if id_token.Email == "" {
+		return database.User{}, errors.New("identity provider did not share an email address")
+	}
+
+	tx, err := cfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+
+	user, err = qtx.GetPasswordFromEmail(ctx, id_token.Email)
+	if err == nil {
+		if !provider.TrustEmail || !id_token.EmailVerified {
+			return database.User{}, errIdentityEmailTaken
+		}
+	} else {
+		user, err = qtx.CreateUser(ctx, database.CreateUserParams{
+			Email: id_token.Email,
+			// No password: the account can only sign in through the provider.
+			HashedPassword: "",
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+		if id_token.EmailVerified {
+			err = qtx.VerifyUserEmail(ctx, database.VerifyUserEmailParams{
+				ID:    user.ID,
+				Email: user.Email,
+			})
+			if err != nil {
+				return database.User{}, err
+			}
+			user.VerifiedAt.Time, user.VerifiedAt.Valid = time.Now(), true
+		}
+	}
+	err = qtx.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		UserID:   user.ID,
+		Provider: provider.Name,
+		Subject:  id_token.Subject,
+		Email:    id_token.Email,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return database.User{}, err
+	}
+	log.Printf("linked %s identity %s to user %s", provider.Name, id_token.Subject, user.ID)
+	return user, nil
+}
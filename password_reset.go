@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/mailer"
+)
+
+const passwordResetTTL = time.Hour
+
+// forgotPassword mails a reset link. It answers the same way whether or
+// not the email belongs to an account, so it can't be used to find users.
+func (cfg *apiConfig) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err := decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	user, err := cfg.DB.GetPasswordFromEmail(r.Context(), param.Email)
+	// Accounts from an external identity provider have no password to reset.
+	if err != nil || user.HashedPassword == "" {
+		return
+	}
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("error creating password reset token: %v", err)
+		return
+	}
+	err = cfg.DB.CreatePasswordResetToken(r.Context(), database.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		log.Printf("error storing password reset token for user %s: %v", user.ID, err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/app/reset-password?token=%s", cfg.BaseURL, url.QueryEscape(token))
+	// Sending happens in the background so the response time doesn't
+	// reveal whether the account exists.
+	cfg.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your Chirpy account.\n\n"+
+			"Open this link within %d minutes to choose a new password:\n%s\n\n"+
+			"If this wasn't you, you can ignore this email.\n", int(passwordResetTTL.Minutes()), link),
+	}, user.ID)
+}
+
+// resetPassword sets a new password using a reset token. The token works
+// once, and every session of the user is signed out afterwards.
+func (cfg *apiConfig) resetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err := decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if param.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "password must not be empty")
+		return
+	}
+	hashed_password, err := cfg.Passwords.Hash(param.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error hashing new password")
+		return
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	userID, err := qtx.ConsumePasswordResetToken(r.Context(), auth.HashToken(param.Token))
+	if err == sql.ErrNoRows {
+		respondWithError(w, http.StatusBadRequest, "reset token invalid or expired")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashed_password,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	err = qtx.InvalidatePasswordResetTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	err = qtx.RevokeAllUserRefreshTokens(r.Context(), database.RevokeAllUserRefreshTokensParams{
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		UserID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.PasswordReset,
+		ActorID:   userID,
+		SubjectID: userID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/blob"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/images"
+	"github.com/google/uuid"
+)
+
+const (
+	maxProfileImageBytes = 10 << 20
+	// Media keys change with their content, so clients and proxies may
+	// keep them forever.
+	mediaCacheControl = "public, max-age=31536000, immutable"
+)
+
+// profileImage is one of the images on a profile.
+type profileImage struct {
+	name string
+	spec images.Spec
+	// set stores the key and returns the previous one.
+	set func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (sql.NullString, error)
+}
+
+var (
+	avatarImage = profileImage{
+		name: "avatars",
+		spec: images.Avatar,
+		set: func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (sql.NullString, error) {
+			return db.SetUserAvatarImage(ctx, database.SetUserAvatarImageParams{ID: userID, AvatarKey: key})
+		},
+	}
+	headerImage = profileImage{
+		name: "headers",
+		spec: images.Header,
+		set: func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (sql.NullString, error) {
+			return db.SetUserHeaderImage(ctx, database.SetUserHeaderImageParams{ID: userID, HeaderKey: key})
+		},
+	}
+)
+
+// mediaURL returns where the blob with key is served, or "" without one.
+func (cfg *apiConfig) mediaURL(key sql.NullString) string {
+	if !key.Valid {
+		return ""
+	}
+	return cfg.MediaBaseURL + key.String
+}
+
+func (cfg *apiConfig) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	cfg.uploadProfileImage(w, r, avatarImage)
+}
+
+func (cfg *apiConfig) uploadHeader(w http.ResponseWriter, r *http.Request) {
+	cfg.uploadProfileImage(w, r, headerImage)
+}
+
+func (cfg *apiConfig) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, avatarImage)
+}
+
+func (cfg *apiConfig) deleteHeader(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, headerImage)
+}
+
+// uploadProfileImage replaces a profile image with the "image" file of a
+// multipart form. The upload is checked by its content, not its name or
+// declared type, then cropped, scaled and stored under a key derived from
+// the result.
+func (cfg *apiConfig) uploadProfileImage(w http.ResponseWriter, r *http.Request, image profileImage) {
+	caller, err := cfg.authorize(r, auth.ScopeProfileEdit)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxProfileImageBytes+(1<<20))
+	file, _, err := r.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "images must be at most 10 MB")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "expected a multipart form with an image file")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxProfileImageBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error reading image")
+		return
+	}
+	if len(data) > maxProfileImageBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "images must be at most 10 MB")
+		return
+	}
+
+	processed, err := images.Process(data, image.spec)
+	if errors.Is(err, images.ErrUnsupported) {
+		respondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+	if errors.Is(err, images.ErrTooLarge) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error processing image")
+		return
+	}
+	sum := sha256.Sum256(processed.Data)
+	key := fmt.Sprintf("%s/%s/%s%s", image.name, caller.UserID, hex.EncodeToString(sum[:16]), processed.Ext)
+	if err := cfg.Blobs.Put(r.Context(), key, processed.ContentType, processed.Data); err != nil {
+		log.Printf("error storing %s: %v", key, err)
+		respondWithError(w, http.StatusInternalServerError, "error storing image")
+		return
+	}
+	previous, err := image.set(r.Context(), cfg.DB, caller.UserID, sql.NullString{String: key, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error storing image")
+		return
+	}
+	if previous.Valid && previous.String != key {
+		cfg.deleteBlob(r.Context(), previous.String)
+	}
+
+	user, err := cfg.DB.GetUserById(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving profile")
+		return
+	}
+	profile, err := cfg.profileFromDB(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving profile")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func (cfg *apiConfig) deleteProfileImage(w http.ResponseWriter, r *http.Request, image profileImage) {
+	caller, err := cfg.authorize(r, auth.ScopeProfileEdit)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	previous, err := image.set(r.Context(), cfg.DB, caller.UserID, sql.NullString{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error removing image")
+		return
+	}
+	if previous.Valid {
+		cfg.deleteBlob(r.Context(), previous.String)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteBlob removes a blob nothing refers to anymore. Failures only
+// leave an unused file behind, so they are logged.
+func (cfg *apiConfig) deleteBlob(ctx context.Context, key string) {
+	if err := cfg.Blobs.Delete(ctx, key); err != nil {
+		log.Printf("error deleting %s: %v", key, err)
+	}
+}
+
+// serveMedia sends a stored blob. Keys contain the hash of their content,
+// which doubles as the ETag.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !blob.ValidKey(key) {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+	etag := `"` + strings.TrimSuffix(path.Base(key), path.Ext(key)) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", mediaCacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	obj, err := cfg.Blobs.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if err != nil {
+		log.Printf("error reading %s: %v", key, err)
+		respondWithError(w, http.StatusInternalServerError, "error reading media")
+		return
+	}
+	defer obj.Body.Close()
+	w.Header().Set("Content-Type", obj.ContentType)
+	if obj.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", mediaCacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, obj.Body)
+}
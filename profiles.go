@@ -0,0 +1,270 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/handles"
+	"github.com/google/uuid"
+)
+
+const (
+	// handleRedirectPeriod is how long an old handle keeps pointing at
+	// its user, and can't be taken by anyone else.
+	handleRedirectPeriod = 30 * 24 * time.Hour
+
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxWebsiteLength     = 100
+)
+
+// Profile is what everyone can see of a user.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	AvatarURL      string    `json:"avatar_url,omitempty"`
+	HeaderURL      string    `json:"header_url,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Red            bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+}
+
+func (cfg *apiConfig) profileFromDB(r *http.Request, user database.User) (Profile, error) {
+	chirps, err := cfg.DB.CountUserChirps(r.Context(), uuid.NullUUID{UUID: user.ID, Valid: true})
+	if err != nil {
+		return Profile{}, err
+	}
+	return Profile{
+		ID:             user.ID,
+		Handle:         user.Handle.String,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		AvatarURL:      cfg.mediaURL(user.AvatarKey),
+		HeaderURL:      cfg.mediaURL(user.HeaderKey),
+		CreatedAt:      user.CreatedAt,
+		Red:            user.IsChirpyRed,
+		ChirpCount:     chirps,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+	}, nil
+}
+
+// getProfile returns the public profile of the user with the handle, or
+// ID, in the path. Old handles redirect to the current one for
+// handleRedirectPeriod.
+func (cfg *apiConfig) getProfile(w http.ResponseWriter, r *http.Request) {
+	value := r.PathValue("handle")
+	var user database.User
+	if id, err := uuid.Parse(value); err == nil {
+		user, err = cfg.DB.GetUserById(r.Context(), id)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+	} else {
+		row, err := cfg.DB.GetUserByHandle(r.Context(), handles.Normalize(value))
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		user = row.User
+		if row.ExpiresAt.Valid && !user.DeleteAfter.Valid {
+			// Not permanent: the old handle is free again once the
+			// redirect expires.
+			http.Redirect(w, r, "/api/users/"+user.Handle.String, http.StatusFound)
+			return
+		}
+	}
+	if user.DeleteAfter.Valid {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	profile, err := cfg.profileFromDB(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving profile")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// validateProfileText trims value and checks it is at most max
+// characters without control characters.
+func validateProfileText(field, value string, max int) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > max {
+		return "", fmt.Errorf("%s must be at most %d characters", field, max)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && (field != "bio" || r != '\n') {
+			return "", fmt.Errorf("%s must not contain control characters", field)
+		}
+	}
+	return value, nil
+}
+
+func validateWebsite(website string) (string, error) {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return "", nil
+	}
+	if len(website) > maxWebsiteLength {
+		return "", fmt.Errorf("website must be at most %d characters", maxWebsiteLength)
+	}
+	parsed, err := url.Parse(website)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("website must be an http or https URL")
+	}
+	return website, nil
+}
+
+// updateProfile changes the caller's public profile. Fields left out of
+// the request stay as they are. A new handle must be free; the old one
+// redirects to it for handleRedirectPeriod.
+func (cfg *apiConfig) updateProfile(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	caller, err := cfg.authorize(r, auth.ScopeProfileEdit)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+
+	update := database.UpdateUserProfileParams{
+		ID:          user.ID,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Location:    user.Location,
+		Website:     user.Website,
+	}
+	if param.DisplayName != nil {
+		if update.DisplayName, err = validateProfileText("display_name", *param.DisplayName, maxDisplayNameLength); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if param.Bio != nil {
+		if update.Bio, err = validateProfileText("bio", *param.Bio, maxBioLength); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if param.Location != nil {
+		if update.Location, err = validateProfileText("location", *param.Location, maxLocationLength); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if param.Website != nil {
+		if update.Website, err = validateWebsite(*param.Website); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	handleChanged := false
+	if param.Handle != nil {
+		handle := strings.TrimPrefix(strings.TrimSpace(*param.Handle), "@")
+		if err := handles.Validate(handle); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		handleChanged = handle != user.Handle.String
+		update.Handle = sql.NullString{String: handle, Valid: true}
+	}
+
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error updating profile")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	if handleChanged {
+		normalized := handles.Normalize(update.Handle.String)
+		err = qtx.RetireHandles(r.Context(), database.RetireHandlesParams{
+			UserID:    user.ID,
+			Handle:    normalized,
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(handleRedirectPeriod), Valid: true},
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error updating profile")
+			return
+		}
+		claimed, err := qtx.ClaimHandle(r.Context(), database.ClaimHandleParams{
+			Handle: normalized,
+			UserID: user.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error updating profile")
+			return
+		}
+		if claimed == 0 {
+			respondWithError(w, http.StatusConflict, "handle is already taken")
+			return
+		}
+	}
+	updated, err := qtx.UpdateUserProfile(r.Context(), update)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error updating profile")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error updating profile")
+		return
+	}
+	if handleChanged {
+		type profile struct {
+			Handle string `json:"handle,omitempty"`
+		}
+		cfg.audit(r, audit.Event{
+			Type:      audit.UserUpdate,
+			ActorID:   user.ID,
+			SubjectID: user.ID,
+			Data:      audit.Diff{Before: profile{Handle: user.Handle.String}, After: profile{Handle: updated.Handle.String}},
+		})
+	}
+
+	profile, err := cfg.profileFromDB(r, updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving profile")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
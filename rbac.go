@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+// Roles and the permissions handlers ask for. Which role holds which
+// permission lives in the role_permissions table.
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+
+	permChirpsDeleteAny = "chirps:delete_any"
+	permAdminMetrics    = "admin:metrics"
+	permAdminReset      = "admin:reset"
+	permUsersUnlock     = "users:unlock"
+	permUsersManageRole = "users:manage_roles"
+	permAuditRead       = "audit:read"
+)
+
+// hasPermission reports whether the user's current role grants permission.
+func (cfg *apiConfig) hasPermission(r *http.Request, userID uuid.UUID, permission string) bool {
+	allowed, err := cfg.DB.UserHasPermission(r.Context(), database.UserHasPermissionParams{
+		ID:         userID,
+		Permission: permission,
+	})
+	if err != nil {
+		log.Printf("error checking permission %s of user %s: %v", permission, userID, err)
+		return false
+	}
+	return allowed
+}
+
+// requirePermission only lets requests through whose user holds
+// permission. The role claim in access tokens is for clients and other
+// services; here the role is read from the database, so demoting a user
+// takes effect immediately rather than when their token expires.
+func (cfg *apiConfig) requirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := cfg.authenticate(r)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Token invalid")
+			return
+		}
+		userID, _ := claims.UserID()
+		if !cfg.hasPermission(r, userID, permission) {
+			respondWithError(w, http.StatusForbidden, "missing permission "+permission)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, userID)))
+	}
+}
+
+type callerKey struct{}
+
+// callerID returns the user requirePermission let through.
+func callerID(r *http.Request) uuid.UUID {
+	id, _ := r.Context().Value(callerKey{}).(uuid.UUID)
+	return id
+}
+
+func (cfg *apiConfig) setUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	switch param.Role {
+	case roleUser, roleModerator, roleAdmin:
+	default:
+		respondWithError(w, http.StatusBadRequest, "unknown role")
+		return
+	}
+	if callerID(r) == userID && param.Role != roleAdmin {
+		respondWithError(w, http.StatusBadRequest, "admins can't demote themselves")
+		return
+	}
+	user, err := cfg.DB.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	updated, err := cfg.DB.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: param.Role,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error updating role")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.AdminRoleChange,
+		ActorID:   callerID(r),
+		SubjectID: userID,
+		Data:      audit.Diff{Before: user.Role, After: param.Role},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bootstrapAdmins gives the admin role to the verified accounts listed in
+// ADMIN_EMAILS at startup, so a fresh deployment has someone to assign
+// roles. Unverified accounts are skipped, since anyone can sign up with
+// any address.
+func bootstrapAdmins(ctx context.Context, db *database.Queries) {
+	emails := []string{}
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) == 0 {
+		return
+	}
+	promoted, err := db.PromoteAdmins(ctx, emails)
+	if err != nil {
+		log.Printf("error promoting ADMIN_EMAILS: %v", err)
+		return
+	}
+	if promoted > 0 {
+		log.Printf("promoted %d accounts from ADMIN_EMAILS to admin", promoted)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/timeline"
+	"github.com/google/uuid"
+)
+
+// reactionLike is stored in chirp_reactions for likes; every other
+// reaction is one of allowedReactions.
+const reactionLike = "like"
+
+const (
+	defaultLikesPageSize = 50
+	maxLikesPageSize     = 200
+)
+
+var allowedReactions = map[string]bool{
+	"👍":  true,
+	"❤️": true,
+	"😂":  true,
+	"😮":  true,
+	"😢":  true,
+	"🎉":  true,
+	"🔥":  true,
+}
+
+type LikedChirp struct {
+	Chirp
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// Likes is a page of liked chirps.
+type Likes struct {
+	Likes []LikedChirp `json:"likes"`
+	// NextCursor continues with ?cursor=; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) likeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setReaction(w, r, reactionLike, true)
+}
+
+func (cfg *apiConfig) unlikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setReaction(w, r, reactionLike, false)
+}
+
+func (cfg *apiConfig) addReaction(w http.ResponseWriter, r *http.Request) {
+	cfg.setReaction(w, r, r.PathValue("reaction"), true)
+}
+
+func (cfg *apiConfig) removeReaction(w http.ResponseWriter, r *http.Request) {
+	cfg.setReaction(w, r, r.PathValue("reaction"), false)
+}
+
+// setReaction adds or removes a reaction of the caller. Both are
+// idempotent: the primary key allows every reaction once per user, and
+// counts are computed from the rows, so concurrent requests can't skew
+// them.
+func (cfg *apiConfig) setReaction(w http.ResponseWriter, r *http.Request, reaction string, add bool) {
+	if reaction != reactionLike && !allowedReactions[reaction] {
+		respondWithError(w, http.StatusBadRequest, "unsupported reaction")
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	params := database.AddChirpReactionParams{
+		ChirpID:  chirpID,
+		UserID:   caller.UserID,
+		Reaction: reaction,
+	}
+	if !add {
+		err = cfg.DB.RemoveChirpReaction(r.Context(), database.RemoveChirpReactionParams(params))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error removing reaction")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	chirp, err := cfg.DB.GetChirpById(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	if blocked, err := cfg.blockedBy(r.Context(), chirp.UserID.UUID, caller.UserID); err != nil || blocked {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	if _, err := cfg.DB.AddChirpReaction(r.Context(), params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error adding reaction")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listUserLikes returns the chirps a user liked, most recent like first.
+// Continue with ?cursor=<next_cursor>.
+func (cfg *apiConfig) listUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving chirps")
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultLikesPageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLikesPageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+	var cursor timeline.Cursor
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = timeline.Decode(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	likedAt, chirpID := cursor.Bounds()
+	params := database.ListUserLikesParams{
+		UserID:    userID,
+		CreatedAt: likedAt,
+		ChirpID:   chirpID,
+		Limit:     int32(limit),
+	}
+
+	rows, err := cfg.DB.ListUserLikes(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving likes")
+		return
+	}
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	hydrated, err := cfg.hydrateChirps(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving likes")
+		return
+	}
+	resp := Likes{Likes: make([]LikedChirp, 0, len(rows))}
+	for i, row := range rows {
+		if visible(filter, &hydrated[i], false) {
+			resp.Likes = append(resp.Likes, LikedChirp{Chirp: hydrated[i], LikedAt: row.LikedAt})
+		}
+	}
+	if len(rows) == limit {
+		last := rows[len(rows)-1]
+		resp.NextCursor = timeline.Cursor{CreatedAt: last.LikedAt, ID: last.Chirp.ID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
+// referencedChirp returns the chirp a rechirp or quote of id refers to.
+// Sharing a rechirp shares its original.
+func (cfg *apiConfig) referencedChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.DB.GetChirpById(ctx, id)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.Kind == chirpKindRechirp {
+		if !chirp.ReferencedChirpID.Valid {
+			return database.Chirp{}, sql.ErrNoRows
+		}
+		chirp, err = cfg.DB.GetChirpById(ctx, chirp.ReferencedChirpID.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+// rechirp shares a chirp with the caller's followers. Every user can
+// rechirp a chirp once.
+func (cfg *apiConfig) rechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	if !cfg.checkCanPost(w, r, caller.UserID) {
+		return
+	}
+	original, err := cfg.referencedChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	if blocked, err := cfg.blockedBy(r.Context(), original.UserID.UUID, caller.UserID); err != nil || blocked {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	tx, err := cfg.Conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rechirping")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	chirp, err := qtx.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		ID:                uuid.New(),
+		UserID:            uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		ReferencedChirpID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "chirp was already rechirped")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rechirping")
+		return
+	}
+	if err := fanOut(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rechirping")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rechirping")
+		return
+	}
+	resp, err := cfg.hydrateChirps(r.Context(), []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error rechirping")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resp[0])
+}
+
+// undoRechirp removes the caller's rechirp of a chirp.
+func (cfg *apiConfig) undoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	caller, err := cfg.authorize(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	deleted, err := cfg.DB.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:            uuid.NullUUID{UUID: caller.UserID, Valid: true},
+		ReferencedChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error removing rechirp")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "rechirp not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+// authenticate validates the bearer access token of the request. Only
+// tokens from a user's own login are accepted; tokens issued to OAuth
+// clients go through authorize and its scope checks.
+func (cfg *apiConfig) authenticate(r *http.Request) (auth.Claims, error) {
+	access_token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+	claims, err := cfg.Keys.ParseClaims(access_token)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+	if claims.ClientID != "" {
+		return auth.Claims{}, errors.New("token was issued to an OAuth client")
+	}
+	return claims, nil
+}
+
+// makeAccessToken issues an access token bound to the session (refresh
+// token family) it was created from.
+func (cfg *apiConfig) makeAccessToken(userID, sessionID uuid.UUID, role string) (string, error) {
+	claims := auth.NewClaims(userID, time.Duration(3600)*time.Second)
+	claims.SessionID = sessionID.String()
+	claims.Role = role
+	return cfg.Keys.Sign(claims)
+}
+
+// sessionID returns the session the access token belongs to, or uuid.Nil
+// for tokens issued before sessions were tracked.
+func sessionID(claims auth.Claims) uuid.UUID {
+	id, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// honoured behind a trusted proxy, since clients can set it freely.
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.TrustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (cfg *apiConfig) listSessions(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	rows, err := cfg.DB.ListUserSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving sessions")
+		return
+	}
+	current := sessionID(claims)
+	sessions := []Session{}
+	for _, row := range rows {
+		sessions = append(sessions, Session{
+			ID:         row.FamilyID,
+			CreatedAt:  row.StartedAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+			UserAgent:  row.UserAgent,
+			IP:         row.Ip,
+			Current:    row.FamilyID == current,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+func (cfg *apiConfig) revokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	revoked, err := cfg.DB.RevokeUserSession(r.Context(), database.RevokeUserSessionParams{
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		FamilyID:  id,
+		UserID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.SessionRevoke,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]uuid.UUID{"session_id": id},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessions signs the user out everywhere. With ?keep_current=true
+// the session making the request stays signed in.
+func (cfg *apiConfig) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	revokedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	if r.URL.Query().Get("keep_current") == "true" {
+		err = cfg.DB.RevokeOtherUserRefreshTokens(r.Context(), database.RevokeOtherUserRefreshTokensParams{
+			RevokedAt: revokedAt,
+			UserID:    userID,
+			FamilyID:  sessionID(claims),
+		})
+	} else {
+		err = cfg.DB.RevokeAllUserRefreshTokens(r.Context(), database.RevokeAllUserRefreshTokensParams{
+			RevokedAt: revokedAt,
+			UserID:    userID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking sessions")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.SessionRevoke,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]bool{"all": true, "keep_current": r.URL.Query().Get("keep_current") == "true"},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
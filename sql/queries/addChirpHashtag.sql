@@ -0,0 +1,4 @@
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags(tag, chirp_id, created_at)
+VALUES($1, $2, $3)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: AddChirpReaction :execrows
+INSERT INTO chirp_reactions(chirp_id, user_id, reaction)
+VALUES($1, $2, $3)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: AddChirpReply :one
+UPDATE chirps SET reply_count = reply_count + 1
+WHERE id = $1 AND deleted_at IS NULL AND kind <> 'rechirp'
+RETURNING conversation_id;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: AdjustFollowCounts :exec
+UPDATE users SET
+    following_count = following_count + CASE WHEN id = sqlc.arg(follower_id) THEN sqlc.arg(delta)::int ELSE 0 END,
+    follower_count = follower_count + CASE WHEN id = sqlc.arg(followee_id) THEN sqlc.arg(delta)::int ELSE 0 END
+WHERE id IN (sqlc.arg(follower_id), sqlc.arg(followee_id));
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- name: AttachMedia :execrows
+UPDATE media_attachments
+SET chirp_id = sqlc.arg(chirp_id),
+    attached_at = NOW(),
+    position = array_position(sqlc.arg(ids)::uuid[], id) - 1
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL
+AND attached_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: BackfillFollowerTimelines :exec
+INSERT INTO timeline_entries(user_id, chirp_id, author_id, created_at)
+SELECT follows.follower_id, recent.id, sqlc.arg(author_id), recent.created_at FROM follows
+CROSS JOIN (
+    SELECT id, created_at FROM chirps
+    WHERE user_id = sqlc.arg(author_id) AND deleted_at IS NULL
+    ORDER BY created_at DESC
+    LIMIT sqlc.arg(max_chirps)
+) AS recent
+WHERE follows.followee_id = sqlc.arg(author_id)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
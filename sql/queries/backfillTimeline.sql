@@ -0,0 +1,7 @@
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries(user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id), chirps.id, chirps.user_id, chirps.created_at FROM chirps
+WHERE chirps.user_id = sqlc.arg(author_id) AND chirps.deleted_at IS NULL
+ORDER BY chirps.created_at DESC
+LIMIT sqlc.arg(max_chirps)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: CancelUserDeletion :execrows
+UPDATE users SET delete_after = NULL, updated_at = NOW()
+WHERE id = $1 AND delete_after IS NOT NULL;
\ No newline at end of file
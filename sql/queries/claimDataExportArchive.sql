@@ -0,0 +1,9 @@
+-- name: ClaimDataExportArchive :one
+UPDATE data_exports SET downloaded_at = NOW(), archive = NULL
+FROM (
+    SELECT id, archive FROM data_exports
+    WHERE id = $1 AND user_id = $2 AND status = 'ready' AND downloaded_at IS NULL AND expires_at > NOW()
+    FOR UPDATE
+) AS ready
+WHERE data_exports.id = ready.id
+RETURNING ready.archive;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: ClaimHandle :execrows
+INSERT INTO handles(handle, user_id)
+VALUES($1, $2)
+ON CONFLICT (handle) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = NOW(), expires_at = NULL
+WHERE handles.user_id = EXCLUDED.user_id OR handles.expires_at <= NOW();
\ No newline at end of file
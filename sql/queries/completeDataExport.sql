@@ -0,0 +1,3 @@
+-- name: CompleteDataExport :exec
+UPDATE data_exports SET status = $2, archive = $3, completed_at = NOW()
+WHERE id = $1;
\ No newline at end of file
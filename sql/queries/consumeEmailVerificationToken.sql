@@ -0,0 +1,4 @@
+-- name: ConsumeEmailVerificationToken :one
+UPDATE email_verification_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING user_id, email;
\ No newline at end of file
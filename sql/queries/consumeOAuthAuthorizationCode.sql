@@ -0,0 +1,4 @@
+-- name: ConsumeOAuthAuthorizationCode :one
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING client_id, user_id, redirect_uri, scopes, code_challenge;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CountChirpReactions :many
+SELECT chirp_id, reaction, COUNT(*) AS count FROM chirp_reactions
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id, reaction;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: CountUserChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CreateBlock :execrows
+INSERT INTO blocks(blocker_id, blocked_id)
+VALUES($1, $2)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
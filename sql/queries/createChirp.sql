@@ -1,7 +1,12 @@
 -- name: CreateChirps :one
-INSERT INTO chirps(body, user_id)
+INSERT INTO chirps(id, body, user_id, in_reply_to, conversation_id, referenced_chirp_id, kind)
 VALUES(
 	$1,
-    $2
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
 )
 RETURNING *;
\ No newline at end of file
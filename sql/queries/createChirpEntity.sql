@@ -0,0 +1,3 @@
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities(chirp_id, kind, start_offset, end_offset, value, user_id)
+VALUES($1, $2, $3, $4, $5, $6);
\ No newline at end of file
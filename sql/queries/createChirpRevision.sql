@@ -0,0 +1,3 @@
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions(chirp_id, body, created_at)
+VALUES($1, $2, $3);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports(id, created_at, user_id, expires_at)
+VALUES (gen_random_uuid(), NOW(), $1, $2)
+ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+RETURNING *;
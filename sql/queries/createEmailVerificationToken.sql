@@ -0,0 +1,8 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens(user_id, email, token_hash, expires_at)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4
+);
\ No newline at end of file
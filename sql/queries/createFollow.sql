@@ -0,0 +1,4 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows(follower_id, followee_id)
+VALUES($1, $2)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
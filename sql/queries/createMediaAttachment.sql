@@ -0,0 +1,4 @@
+-- name: CreateMediaAttachment :one
+INSERT INTO media_attachments(id, user_id, kind, key, width, height, thumbnail_key, thumbnail_width, thumbnail_height, blurhash, alt_text)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CreateMute :exec
+INSERT INTO mutes(muter_id, muted_id)
+VALUES($1, $2)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
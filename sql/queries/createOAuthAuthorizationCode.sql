@@ -0,0 +1,11 @@
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients(owner_id, name, secret_hash, redirect_uris, scopes)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
\ No newline at end of file
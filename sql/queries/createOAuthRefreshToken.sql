@@ -0,0 +1,9 @@
+-- name: CreateOAuthRefreshToken :exec
+INSERT INTO oauth_refresh_tokens(token_hash, client_id, user_id, scopes, expires_at)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens(user_id, token_hash, expires_at)
+VALUES(
+    $1,
+    $2,
+    $3
+);
\ No newline at end of file
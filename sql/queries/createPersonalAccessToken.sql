@@ -0,0 +1,10 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens(user_id, name, token_hash, scopes, expires_at)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps(id, body, user_id, conversation_id, referenced_chirp_id, kind)
+VALUES($1, '', $2, $1, $3, 'rechirp')
+ON CONFLICT (user_id, referenced_chirp_id) WHERE kind = 'rechirp' DO NOTHING
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: CreateRecoveryCode :exec
+INSERT INTO mfa_recovery_codes(user_id, code_hash)
+VALUES(
+    $1,
+    $2
+);
\ No newline at end of file
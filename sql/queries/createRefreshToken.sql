@@ -1,8 +1,11 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_token(token, user_id, expires_at)
+INSERT INTO refresh_token(token, user_id, expires_at, family_id, user_agent, ip)
 VALUES(
     $1,
     $2,
-    $3
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities(user_id, provider, subject, email)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4
+);
\ No newline at end of file
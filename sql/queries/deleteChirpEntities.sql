@@ -0,0 +1,2 @@
+-- name: DeleteChirpEntities :exec
+DELETE FROM chirp_entities WHERE chirp_id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: DeleteChirpMedia :many
+DELETE FROM media_attachments
+WHERE chirp_id = $1
+RETURNING key, thumbnail_key;
\ No newline at end of file
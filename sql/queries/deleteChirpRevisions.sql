@@ -0,0 +1,2 @@
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions WHERE chirp_id = $1;
\ No newline at end of file
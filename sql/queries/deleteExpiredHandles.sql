@@ -0,0 +1,3 @@
+-- name: DeleteExpiredHandles :exec
+DELETE FROM handles
+WHERE expires_at <= NOW();
\ No newline at end of file
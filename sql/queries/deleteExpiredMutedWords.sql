@@ -0,0 +1,3 @@
+-- name: DeleteExpiredMutedWords :exec
+DELETE FROM muted_words
+WHERE expires_at <= NOW();
\ No newline at end of file
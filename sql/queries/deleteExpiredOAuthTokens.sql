@@ -0,0 +1,2 @@
+-- name: DeleteExpiredOAuthTokens :exec
+DELETE FROM oauth_revoked_access_tokens WHERE expires_at < NOW();
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: DeleteFollowsOfDeletedUsers :exec
+WITH purged AS (
+    SELECT id FROM users WHERE delete_after <= NOW() FOR UPDATE
+), removed AS (
+    DELETE FROM follows
+    WHERE follower_id IN (SELECT id FROM purged) OR followee_id IN (SELECT id FROM purged)
+    RETURNING follower_id, followee_id
+), counts AS (
+    SELECT id, SUM(following)::int AS following, SUM(followers)::int AS followers FROM (
+        SELECT follower_id AS id, 1 AS following, 0 AS followers FROM removed
+        UNION ALL
+        SELECT followee_id, 0, 1 FROM removed
+    ) AS changes
+    GROUP BY id
+)
+UPDATE users SET
+    following_count = following_count - counts.following,
+    follower_count = follower_count - counts.followers
+FROM counts
+WHERE users.id = counts.id;
\ No newline at end of file
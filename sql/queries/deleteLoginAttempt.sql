@@ -0,0 +1,2 @@
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts WHERE key = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients WHERE id = $1 AND owner_id = $2;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: DeleteOrphanedMedia :many
+DELETE FROM media_attachments
+WHERE chirp_id IS NULL
+AND (attached_at IS NOT NULL OR user_id IS NULL OR created_at < $1)
+RETURNING key, thumbnail_key;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeleteOrphanedRechirps :exec
+DELETE FROM chirps
+WHERE referenced_chirp_id IS NULL AND kind = 'rechirp';
\ No newline at end of file
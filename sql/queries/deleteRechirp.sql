@@ -0,0 +1,3 @@
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1 AND referenced_chirp_id = $2 AND kind = 'rechirp';
\ No newline at end of file
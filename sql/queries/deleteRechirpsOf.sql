@@ -0,0 +1,3 @@
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE referenced_chirp_id = $1 AND kind = 'rechirp';
\ No newline at end of file
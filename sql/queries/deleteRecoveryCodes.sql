@@ -0,0 +1,2 @@
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeleteTimelineEntriesFromAuthor :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1 AND author_id = $2;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DisableUserTOTP :exec
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW() WHERE id = $1;
\ No newline at end of file
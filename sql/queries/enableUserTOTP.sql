@@ -0,0 +1,2 @@
+-- name: EnableUserTOTP :exec
+UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW() WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: FailStaleDataExports :exec
+UPDATE data_exports SET status = 'failed', completed_at = NOW()
+WHERE status = 'pending' AND created_at < $1;
\ No newline at end of file
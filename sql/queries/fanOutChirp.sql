@@ -0,0 +1,8 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries(user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(author_id), sqlc.arg(chirp_id), sqlc.arg(author_id), sqlc.arg(created_at)
+UNION ALL
+SELECT follows.follower_id, sqlc.arg(chirp_id), sqlc.arg(author_id), sqlc.arg(created_at) FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.followee_id = sqlc.arg(author_id) AND users.follower_count <= sqlc.arg(max_followers)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: AllchirpsFromUser :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
\ No newline at end of file
+SELECT * FROM chirps WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at;
\ No newline at end of file
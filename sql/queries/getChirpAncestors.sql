@@ -0,0 +1,11 @@
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT chirps.id, chirps.in_reply_to, 0 FROM chirps WHERE chirps.id = $1
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps JOIN ancestors ON chirps.id = ancestors.in_reply_to
+)
+SELECT chirps.* FROM ancestors
+JOIN chirps ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+ORDER BY ancestors.depth DESC;
\ No newline at end of file
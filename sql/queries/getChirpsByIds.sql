@@ -0,0 +1,2 @@
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]);
\ No newline at end of file
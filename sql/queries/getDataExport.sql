@@ -0,0 +1,3 @@
+-- name: GetDataExport :one
+SELECT id, created_at, status, completed_at, downloaded_at, expires_at FROM data_exports
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
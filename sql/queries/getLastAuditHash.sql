@@ -0,0 +1,2 @@
+-- name: GetLastAuditHash :one
+SELECT hash FROM audit_events ORDER BY seq DESC LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetMediaAttachmentsByIds :many
+SELECT * FROM media_attachments
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
\ No newline at end of file
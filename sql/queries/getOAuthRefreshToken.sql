@@ -0,0 +1,2 @@
+-- name: GetOAuthRefreshToken :one
+SELECT * FROM oauth_refresh_tokens WHERE token_hash = $1;
\ No newline at end of file
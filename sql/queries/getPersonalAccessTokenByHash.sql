@@ -0,0 +1,2 @@
+-- name: GetPersonalAccessTokenByHash :one
+SELECT * FROM personal_access_tokens WHERE token_hash = $1;
\ No newline at end of file
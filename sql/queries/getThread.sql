@@ -0,0 +1,15 @@
+-- name: GetThread :many
+WITH RECURSIVE thread(id, depth, path) AS (
+    SELECT chirps.id, 0, ARRAY[to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text]
+    FROM chirps WHERE chirps.id = sqlc.arg(root_id)
+    UNION ALL
+    SELECT chirps.id, thread.depth + 1, thread.path || (to_char(chirps.created_at, 'YYYYMMDDHH24MISSUS') || chirps.id::text)
+    FROM chirps JOIN thread ON chirps.in_reply_to = thread.id
+    WHERE thread.depth < sqlc.arg(max_depth)::int
+)
+SELECT sqlc.embed(chirps), thread.depth FROM thread
+JOIN chirps ON chirps.id = thread.id
+WHERE sqlc.narg(after_id)::uuid IS NULL
+    OR thread.path > (SELECT after.path FROM thread AS after WHERE after.id = sqlc.narg(after_id))
+ORDER BY thread.path
+LIMIT sqlc.arg(max_chirps);
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetUserByHandle :one
+SELECT sqlc.embed(users), handles.expires_at FROM handles
+JOIN users ON users.id = handles.user_id
+WHERE handles.handle = $1 AND (handles.expires_at IS NULL OR handles.expires_at > NOW());
\ No newline at end of file
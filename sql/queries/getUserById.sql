@@ -0,0 +1,2 @@
+-- name: GetUserById :one
+SELECT * FROM users WHERE id = $1;
\ No newline at end of file
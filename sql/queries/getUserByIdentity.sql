@@ -0,0 +1,4 @@
+-- name: GetUserByIdentity :one
+SELECT users.* FROM users
+JOIN user_identities ON user_identities.user_id = users.id
+WHERE user_identities.provider = $1 AND user_identities.subject = $2;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: GetUserFromRefreshToken :one
-SELECT users.id, refresh_token.token, refresh_token.expires_at, refresh_token.revoked_at FROM refresh_token JOIN users ON users.id = refresh_token.user_id WHERE token = $1;
\ No newline at end of file
+SELECT users.id, users.role, refresh_token.token, refresh_token.expires_at, refresh_token.revoked_at, refresh_token.family_id, refresh_token.replaced_by FROM refresh_token JOIN users ON users.id = refresh_token.user_id WHERE token = $1;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- name: InsertAuditEvent :one
+INSERT INTO audit_events(id, created_at, event_type, actor_id, subject_id, ip, data, prev_hash, hash)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING seq;
\ No newline at end of file
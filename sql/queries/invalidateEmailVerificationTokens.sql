@@ -0,0 +1,2 @@
+-- name: InvalidateEmailVerificationTokens :exec
+UPDATE email_verification_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: IsBlocked :one
+SELECT EXISTS(
+    SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = $2
+);
\ No newline at end of file
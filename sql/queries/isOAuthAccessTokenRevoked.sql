@@ -0,0 +1,2 @@
+-- name: IsOAuthAccessTokenRevoked :one
+SELECT EXISTS(SELECT 1 FROM oauth_revoked_access_tokens WHERE jti = $1);
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(user_id)::uuid IS NULL OR subject_id = sqlc.narg(user_id) OR actor_id = sqlc.narg(user_id))
+    AND (sqlc.narg(event_type)::text IS NULL OR event_type = sqlc.narg(event_type))
+    AND seq < sqlc.arg(before_seq)
+ORDER BY seq DESC
+LIMIT sqlc.arg(max_events);
\ No newline at end of file
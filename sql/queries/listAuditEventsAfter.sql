@@ -0,0 +1,2 @@
+-- name: ListAuditEventsAfter :many
+SELECT * FROM audit_events WHERE seq > $1 ORDER BY seq LIMIT $2;
\ No newline at end of file
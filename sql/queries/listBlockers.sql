@@ -0,0 +1,3 @@
+-- name: ListBlockers :many
+SELECT blocker_id FROM blocks
+WHERE blocked_id = $1;
\ No newline at end of file
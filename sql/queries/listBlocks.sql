@@ -0,0 +1,4 @@
+-- name: ListBlocks :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC;
\ No newline at end of file
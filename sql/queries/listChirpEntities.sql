@@ -0,0 +1,4 @@
+-- name: ListChirpEntities :many
+SELECT * FROM chirp_entities
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, start_offset;
\ No newline at end of file
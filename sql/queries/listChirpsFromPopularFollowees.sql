@@ -0,0 +1,8 @@
+-- name: ListChirpsFromPopularFollowees :many
+SELECT sqlc.embed(chirps) FROM follows
+JOIN users ON users.id = follows.followee_id
+JOIN chirps ON chirps.user_id = follows.followee_id
+WHERE follows.follower_id = $1 AND users.follower_count > $2
+    AND (chirps.created_at, chirps.id) < ($3, $4) AND chirps.deleted_at IS NULL
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $5;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: ListFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = $1 AND (created_at, follower_id) < ($2, $3)
+ORDER BY created_at DESC, follower_id DESC
+LIMIT $4;
\ No newline at end of file
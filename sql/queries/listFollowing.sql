@@ -0,0 +1,5 @@
+-- name: ListFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = $1 AND (created_at, followee_id) < ($2, $3)
+ORDER BY created_at DESC, followee_id DESC
+LIMIT $4;
\ No newline at end of file
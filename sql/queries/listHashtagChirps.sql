@@ -0,0 +1,7 @@
+-- name: ListHashtagChirps :many
+SELECT sqlc.embed(chirps) FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = $1 AND (chirp_hashtags.created_at, chirp_hashtags.chirp_id) < ($2, $3)
+    AND chirps.deleted_at IS NULL
+ORDER BY chirp_hashtags.created_at DESC, chirp_hashtags.chirp_id DESC
+LIMIT $4;
\ No newline at end of file
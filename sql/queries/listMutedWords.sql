@@ -0,0 +1,4 @@
+-- name: ListMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC;
\ No newline at end of file
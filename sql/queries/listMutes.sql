@@ -0,0 +1,4 @@
+-- name: ListMutes :many
+SELECT muted_id AS user_id, created_at FROM mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: ListPurgeableChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND (user_id IS NULL OR user_id IN (SELECT id FROM users WHERE delete_after <= NOW()))
+ORDER BY created_at DESC;
\ No newline at end of file
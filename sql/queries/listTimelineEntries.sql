@@ -0,0 +1,7 @@
+-- name: ListTimelineEntries :many
+SELECT sqlc.embed(chirps) FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = $1 AND (timeline_entries.created_at, timeline_entries.chirp_id) < ($2, $3)
+    AND chirps.deleted_at IS NULL
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT $4;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: ListUserLikes :many
+SELECT sqlc.embed(chirps), chirp_reactions.created_at AS liked_at FROM chirp_reactions
+JOIN chirps ON chirps.id = chirp_reactions.chirp_id
+WHERE chirp_reactions.user_id = $1 AND chirp_reactions.reaction = 'like'
+    AND chirps.deleted_at IS NULL AND (chirp_reactions.created_at, chirp_reactions.chirp_id) < ($2, $3)
+ORDER BY chirp_reactions.created_at DESC, chirp_reactions.chirp_id DESC
+LIMIT $4;
\ No newline at end of file
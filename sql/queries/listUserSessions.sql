@@ -0,0 +1,6 @@
+-- name: ListUserSessions :many
+SELECT refresh_token.family_id, refresh_token.user_agent, refresh_token.ip, refresh_token.last_used_at, refresh_token.expires_at,
+    (SELECT MIN(family.created_at) FROM refresh_token AS family WHERE family.family_id = refresh_token.family_id)::timestamp AS started_at
+FROM refresh_token
+WHERE refresh_token.user_id = $1 AND refresh_token.revoked_at IS NULL AND refresh_token.expires_at > NOW()
+ORDER BY refresh_token.last_used_at DESC;
\ No newline at end of file
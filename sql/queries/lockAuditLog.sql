@@ -0,0 +1,2 @@
+-- name: LockAuditLog :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_events'));
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: LockChirp :one
+SELECT * FROM chirps WHERE id = $1 FOR UPDATE;
\ No newline at end of file
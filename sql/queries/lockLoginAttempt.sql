@@ -0,0 +1,2 @@
+-- name: LockLoginAttempt :exec
+UPDATE login_attempts SET locked_until = $2 WHERE key = $1;
\ No newline at end of file
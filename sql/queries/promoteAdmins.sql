@@ -0,0 +1,3 @@
+-- name: PromoteAdmins :execrows
+UPDATE users SET role = 'admin', updated_at = NOW()
+WHERE email = ANY(sqlc.arg(emails)::text[]) AND verified_at IS NOT NULL AND role <> 'admin';
\ No newline at end of file
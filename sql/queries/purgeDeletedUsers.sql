@@ -0,0 +1,3 @@
+-- name: PurgeDeletedUsers :many
+DELETE FROM users WHERE delete_after <= NOW()
+RETURNING id, avatar_key, header_key;
\ No newline at end of file
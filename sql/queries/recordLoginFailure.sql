@@ -0,0 +1,11 @@
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts(key, failures, last_failure_at)
+VALUES(
+    sqlc.arg(key),
+    1,
+    sqlc.arg(now)
+)
+ON CONFLICT (key) DO UPDATE SET
+    failures = CASE WHEN login_attempts.last_failure_at < sqlc.arg(window_start) THEN 1 ELSE login_attempts.failures + 1 END,
+    last_failure_at = sqlc.arg(now)
+RETURNING failures;
\ No newline at end of file
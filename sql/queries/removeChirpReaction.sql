@@ -0,0 +1,3 @@
+-- name: RemoveChirpReaction :exec
+DELETE FROM chirp_reactions
+WHERE chirp_id = $1 AND user_id = $2 AND reaction = $3;
\ No newline at end of file
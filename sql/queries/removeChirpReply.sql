@@ -0,0 +1,4 @@
+-- name: RemoveChirpReply :one
+UPDATE chirps SET reply_count = reply_count - 1
+WHERE id = $1 AND reply_count > 0
+RETURNING reply_count, deleted_at, in_reply_to;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: ResolveHandles :many
+SELECT handle, user_id FROM handles
+WHERE handle = ANY(sqlc.arg(handles)::text[]) AND expires_at IS NULL;
\ No newline at end of file
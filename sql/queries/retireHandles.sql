@@ -0,0 +1,3 @@
+-- name: RetireHandles :exec
+UPDATE handles SET expires_at = $3
+WHERE user_id = $1 AND handle <> $2 AND expires_at IS NULL;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: Allchirps :many
-SELECT * FROM chirps ORDER BY created_at;
\ No newline at end of file
+SELECT * FROM chirps WHERE deleted_at IS NULL ORDER BY created_at;
\ No newline at end of file
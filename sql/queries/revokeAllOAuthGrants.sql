@@ -0,0 +1,3 @@
+-- name: RevokeAllOAuthGrants :exec
+UPDATE oauth_refresh_tokens SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: RevokeOAuthAccessToken :exec
+INSERT INTO oauth_revoked_access_tokens(jti, expires_at)
+VALUES($1, $2)
+ON CONFLICT (jti) DO NOTHING;
\ No newline at end of file
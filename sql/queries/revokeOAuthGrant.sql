@@ -0,0 +1,3 @@
+-- name: RevokeOAuthGrant :exec
+UPDATE oauth_refresh_tokens SET revoked_at = NOW()
+WHERE client_id = $1 AND user_id = $2 AND revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: RevokeOtherUserRefreshTokens :exec
+UPDATE refresh_token SET revoked_at = $1, updated_at = $1 WHERE user_id = $2 AND family_id <> $3 AND revoked_at IS NULL;
\ No newline at end of file
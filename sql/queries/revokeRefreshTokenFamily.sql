@@ -0,0 +1,2 @@
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_token SET revoked_at = $1, updated_at = $1 WHERE family_id = $2 AND revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: RevokeUserSession :execrows
+UPDATE refresh_token SET revoked_at = $1, updated_at = $1 WHERE family_id = $2 AND user_id = $3 AND revoked_at IS NULL;
\ No newline at end of file
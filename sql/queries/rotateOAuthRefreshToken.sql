@@ -0,0 +1,3 @@
+-- name: RotateOAuthRefreshToken :execrows
+UPDATE oauth_refresh_tokens SET revoked_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW();
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_token SET revoked_at = $1, updated_at = $1, replaced_by = $2 WHERE token = $3 AND revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: ScheduleUserDeletion :execrows
+UPDATE users SET delete_after = $2, updated_at = NOW()
+WHERE id = $1 AND delete_after IS NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: SetUserAvatarImage :one
+UPDATE users SET avatar_key = $2, updated_at = NOW()
+FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) AS previous
+WHERE users.id = previous.id
+RETURNING previous.avatar_key;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: SetUserHeaderImage :one
+UPDATE users SET header_key = $2, updated_at = NOW()
+FROM (SELECT id, header_key FROM users WHERE id = $1 FOR UPDATE) AS previous
+WHERE users.id = previous.id
+RETURNING previous.header_key;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: SetUserTOTPSecret :execrows
+UPDATE users SET totp_secret = $2, updated_at = NOW() WHERE id = $1 AND totp_enabled_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: TakeEntityBackfill :many
+DELETE FROM entity_backfill
+WHERE chirp_id IN (SELECT chirp_id FROM entity_backfill LIMIT $1 FOR UPDATE SKIP LOCKED)
+RETURNING chirp_id;
\ No newline at end of file
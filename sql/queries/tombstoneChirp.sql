@@ -0,0 +1,3 @@
+-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
\ No newline at end of file
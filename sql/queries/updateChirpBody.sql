@@ -0,0 +1,4 @@
+-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, updated_at = NOW(), edited_at = NOW()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
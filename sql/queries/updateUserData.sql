@@ -1,2 +0,0 @@
--- name: UpdateUserData :exec
-UPDATE users SET email = $2, hashed_password = $3, updated_at = $4 WHERE id = $1;
\ No newline at end of file
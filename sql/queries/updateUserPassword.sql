@@ -0,0 +1,2 @@
+-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = $2, updated_at = NOW() WHERE id = $1;
\ No newline at end of file
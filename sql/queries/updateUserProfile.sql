@@ -0,0 +1,10 @@
+-- name: UpdateUserProfile :one
+UPDATE users SET
+    handle = $2,
+    display_name = $3,
+    bio = $4,
+    location = $5,
+    website = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
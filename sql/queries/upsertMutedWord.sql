@@ -0,0 +1,5 @@
+-- name: UpsertMutedWord :one
+INSERT INTO muted_words(user_id, word, expires_at)
+VALUES($1, $2, $3)
+ON CONFLICT (user_id, word) DO UPDATE SET expires_at = EXCLUDED.expires_at
+RETURNING *;
\ No newline at end of file
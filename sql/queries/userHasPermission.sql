@@ -0,0 +1,6 @@
+-- name: UserHasPermission :one
+SELECT EXISTS(
+    SELECT 1 FROM users
+    JOIN role_permissions ON role_permissions.role = users.role
+    WHERE users.id = $1 AND role_permissions.permission = $2
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: VerifyUserEmail :exec
+UPDATE users SET email = $2, verified_at = NOW(), updated_at = NOW() WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users
+ADD totp_secret TEXT DEFAULT NULL,
+ADD totp_enabled_at TIMESTAMP DEFAULT NULL,
+ADD totp_last_step BIGINT NOT NULL DEFAULT 0;
+CREATE TABLE mfa_recovery_codes(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (user_id, code_hash)
+);
+-- +goose Down
+DROP TABLE mfa_recovery_codes;
+ALTER TABLE users
+DROP totp_secret,
+DROP totp_enabled_at,
+DROP totp_last_step;
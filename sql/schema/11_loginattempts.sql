@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE login_attempts(
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP DEFAULT NULL
+);
+-- +goose Down
+DROP TABLE login_attempts;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE user_identities(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    UNIQUE (provider, subject)
+);
+CREATE INDEX user_identities_user_id_idx ON user_identities(user_id);
+-- +goose Down
+DROP TABLE user_identities;
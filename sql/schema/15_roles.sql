@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE roles(
+    name TEXT PRIMARY KEY
+);
+INSERT INTO roles(name) VALUES ('user'), ('moderator'), ('admin');
+
+CREATE TABLE role_permissions(
+    role TEXT NOT NULL,
+    FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+INSERT INTO role_permissions(role, permission) VALUES
+    ('moderator', 'chirps:delete_any'),
+    ('admin', 'chirps:delete_any'),
+    ('admin', 'admin:metrics'),
+    ('admin', 'admin:reset'),
+    ('admin', 'users:unlock'),
+    ('admin', 'users:manage_roles');
+
+ALTER TABLE users
+ADD role TEXT NOT NULL DEFAULT 'user' REFERENCES roles(name);
+-- +goose Down
+ALTER TABLE users
+DROP role;
+DROP TABLE role_permissions;
+DROP TABLE roles;
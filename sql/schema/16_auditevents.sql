@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE audit_events(
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    event_type TEXT NOT NULL,
+    actor_id UUID DEFAULT NULL,
+    subject_id UUID DEFAULT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    data JSON NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL
+);
+CREATE INDEX audit_events_subject_id_idx ON audit_events(subject_id, seq);
+CREATE INDEX audit_events_actor_id_idx ON audit_events(actor_id, seq);
+CREATE INDEX audit_events_event_type_idx ON audit_events(event_type, seq);
+
+INSERT INTO role_permissions(role, permission) VALUES ('admin', 'audit:read');
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TABLE audit_events;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users
+ADD delete_after TIMESTAMP DEFAULT NULL;
+CREATE INDEX users_delete_after_idx ON users(delete_after) WHERE delete_after IS NOT NULL;
+CREATE TABLE data_exports(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    archive BYTEA DEFAULT NULL,
+    completed_at TIMESTAMP DEFAULT NULL,
+    downloaded_at TIMESTAMP DEFAULT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX data_exports_pending_idx ON data_exports(user_id) WHERE status = 'pending';
+-- +goose Down
+DROP TABLE data_exports;
+ALTER TABLE users
+DROP delete_after;
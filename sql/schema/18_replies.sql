@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD in_reply_to UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE SET NULL,
+ADD conversation_id UUID,
+ADD reply_count INTEGER NOT NULL DEFAULT 0,
+ADD deleted_at TIMESTAMP DEFAULT NULL;
+UPDATE chirps SET conversation_id = id;
+ALTER TABLE chirps
+ALTER conversation_id SET NOT NULL;
+CREATE INDEX chirps_in_reply_to_idx ON chirps(in_reply_to, created_at);
+CREATE INDEX chirps_conversation_id_idx ON chirps(conversation_id);
+-- +goose Down
+ALTER TABLE chirps
+DROP in_reply_to,
+DROP conversation_id,
+DROP reply_count,
+DROP deleted_at;
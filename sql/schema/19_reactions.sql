@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_reactions(
+    chirp_id UUID NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    reaction TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chirp_id, reaction, user_id)
+);
+CREATE INDEX chirp_reactions_user_id_idx ON chirp_reactions(user_id, reaction, created_at);
+-- +goose Down
+DROP TABLE chirp_reactions;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD referenced_chirp_id UUID DEFAULT NULL REFERENCES chirps(id) ON DELETE SET NULL,
+ADD kind TEXT NOT NULL DEFAULT 'chirp';
+CREATE INDEX chirps_referenced_chirp_id_idx ON chirps(referenced_chirp_id);
+CREATE UNIQUE INDEX chirps_rechirp_idx ON chirps(user_id, referenced_chirp_id) WHERE kind = 'rechirp';
+-- +goose Down
+DROP INDEX chirps_rechirp_idx;
+ALTER TABLE chirps
+DROP referenced_chirp_id,
+DROP kind;
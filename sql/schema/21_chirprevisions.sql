@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD edited_at TIMESTAMP DEFAULT NULL;
+CREATE TABLE chirp_revisions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chirp_id UUID NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX chirp_revisions_chirp_id_idx ON chirp_revisions(chirp_id, replaced_at);
+-- +goose Down
+DROP TABLE chirp_revisions;
+ALTER TABLE chirps
+DROP edited_at;
@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE follows(
+    follower_id UUID NOT NULL,
+    FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL,
+    FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+CREATE INDEX follows_followee_id_idx ON follows(followee_id, created_at);
+CREATE INDEX follows_follower_id_idx ON follows(follower_id, created_at);
+ALTER TABLE users
+ADD follower_count INTEGER NOT NULL DEFAULT 0,
+ADD following_count INTEGER NOT NULL DEFAULT 0;
+
+-- Home timelines are written when a chirp is posted (fan-out on write),
+-- except for authors with very many followers, whose chirps are read from
+-- chirps when the timeline is requested.
+CREATE TABLE timeline_entries(
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX timeline_entries_user_id_idx ON timeline_entries(user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX timeline_entries_author_id_idx ON timeline_entries(user_id, author_id);
+CREATE INDEX chirps_user_id_created_at_idx ON chirps(user_id, created_at DESC, id DESC);
+INSERT INTO timeline_entries(user_id, chirp_id, author_id, created_at)
+SELECT user_id, id, user_id, created_at FROM chirps WHERE deleted_at IS NULL;
+-- +goose Down
+DROP INDEX chirps_user_id_created_at_idx;
+DROP TABLE timeline_entries;
+ALTER TABLE users
+DROP follower_count,
+DROP following_count;
+DROP TABLE follows;
@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL,
+    FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL,
+    FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+CREATE INDEX blocks_blocked_id_idx ON blocks(blocked_id);
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL,
+    FOREIGN KEY (muter_id) REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL,
+    FOREIGN KEY (muted_id) REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+CREATE TABLE muted_words(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    -- Lower case with single spaces, see mutes.NormalizeWord.
+    word TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    UNIQUE (user_id, word)
+);
+CREATE INDEX muted_words_expires_at_idx ON muted_words(expires_at) WHERE expires_at IS NOT NULL;
+-- +goose Down
+DROP TABLE muted_words;
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users
+ADD handle TEXT,
+ADD display_name TEXT NOT NULL DEFAULT '',
+ADD bio TEXT NOT NULL DEFAULT '',
+ADD location TEXT NOT NULL DEFAULT '',
+ADD website TEXT NOT NULL DEFAULT '';
+-- users.handle keeps the case the user chose; handles holds the lower
+-- case form of current handles and, until expires_at, of old ones that
+-- redirect to their user's current handle.
+CREATE TABLE handles(
+    handle TEXT PRIMARY KEY,
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP
+);
+CREATE INDEX handles_user_id_idx ON handles(user_id);
+CREATE UNIQUE INDEX handles_current_idx ON handles(user_id) WHERE expires_at IS NULL;
+-- +goose Down
+DROP TABLE handles;
+ALTER TABLE users
+DROP handle,
+DROP display_name,
+DROP bio,
+DROP location,
+DROP website;
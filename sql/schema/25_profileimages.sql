@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users
+ADD avatar_key TEXT,
+ADD header_key TEXT;
+-- +goose Down
+ALTER TABLE users
+DROP avatar_key,
+DROP header_key;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE media_attachments(
+    id UUID PRIMARY KEY,
+    -- Owners and chirps are set to NULL rather than cascading, so the
+    -- purger still finds the blobs to delete.
+    user_id UUID,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    chirp_id UUID,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    -- "image" or "gif".
+    kind TEXT NOT NULL,
+    key TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    thumbnail_width INTEGER NOT NULL,
+    thumbnail_height INTEGER NOT NULL,
+    blurhash TEXT NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    attached_at TIMESTAMP
+);
+CREATE INDEX media_attachments_chirp_id_idx ON media_attachments(chirp_id, position);
+CREATE INDEX media_attachments_unattached_idx ON media_attachments(created_at) WHERE chirp_id IS NULL;
+-- +goose Down
+DROP TABLE media_attachments;
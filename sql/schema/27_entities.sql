@@ -0,0 +1,33 @@
+-- +goose Up
+-- Hashtags, mentions and URLs in chirp bodies, see internal/entities.
+-- Chirps posted before this migration get theirs from 30_entitybackfill.
+CREATE TABLE chirp_entities(
+    chirp_id UUID NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    -- "hashtag", "mention" or "url".
+    kind TEXT NOT NULL,
+    -- Code point offsets into the body; end_offset is exclusive.
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    -- The normalized tag, the handle as written, or the URL.
+    value TEXT NOT NULL,
+    -- The mentioned user.
+    user_id UUID,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+CREATE INDEX chirp_entities_user_id_idx ON chirp_entities(user_id) WHERE user_id IS NOT NULL;
+-- One row per tag and chirp, however often the tag appears, with the
+-- chirp's created_at so tag pages are read in index order.
+CREATE TABLE chirp_hashtags(
+    tag TEXT NOT NULL,
+    chirp_id UUID NOT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tag, chirp_id)
+);
+CREATE INDEX chirp_hashtags_tag_created_at_idx ON chirp_hashtags(tag, created_at DESC, chirp_id DESC);
+CREATE INDEX chirp_hashtags_chirp_id_idx ON chirp_hashtags(chirp_id);
+-- +goose Down
+DROP TABLE chirp_hashtags;
+DROP TABLE chirp_entities;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Likes are paged by (created_at, chirp_id), like timelines.
+DROP INDEX chirp_reactions_user_id_idx;
+CREATE INDEX chirp_reactions_user_id_idx ON chirp_reactions(user_id, reaction, created_at, chirp_id);
+-- +goose Down
+DROP INDEX chirp_reactions_user_id_idx;
+CREATE INDEX chirp_reactions_user_id_idx ON chirp_reactions(user_id, reaction, created_at);
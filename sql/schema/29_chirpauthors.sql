@@ -0,0 +1,14 @@
+-- +goose Up
+-- Tombstones outlive the accounts that wrote them, so the conversations
+-- below them stay connected. Every other chirp is removed before its
+-- author's account is deleted.
+ALTER TABLE chirps
+ALTER user_id DROP NOT NULL,
+DROP CONSTRAINT chirps_user_id_fkey,
+ADD CONSTRAINT chirps_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+-- +goose Down
+DELETE FROM chirps WHERE user_id IS NULL;
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_user_id_fkey,
+ADD CONSTRAINT chirps_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+ALTER user_id SET NOT NULL;
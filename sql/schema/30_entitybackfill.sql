@@ -0,0 +1,14 @@
+-- +goose Up
+-- Chirps posted before 27_entities have no entities yet. A job started
+-- with the server extracts theirs in batches and empties this table as it
+-- goes.
+CREATE TABLE entity_backfill(
+    chirp_id UUID PRIMARY KEY,
+    FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+INSERT INTO entity_backfill(chirp_id)
+SELECT id FROM chirps
+WHERE deleted_at IS NULL AND body <> ''
+    AND NOT EXISTS (SELECT 1 FROM chirp_entities WHERE chirp_entities.chirp_id = chirps.id);
+-- +goose Down
+DROP TABLE entity_backfill;
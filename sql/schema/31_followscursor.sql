@@ -0,0 +1,11 @@
+-- +goose Up
+-- Follow lists are paged by (created_at, user), like timelines.
+DROP INDEX follows_followee_id_idx;
+DROP INDEX follows_follower_id_idx;
+CREATE INDEX follows_followee_id_idx ON follows(followee_id, created_at, follower_id);
+CREATE INDEX follows_follower_id_idx ON follows(follower_id, created_at, followee_id);
+-- +goose Down
+DROP INDEX follows_followee_id_idx;
+DROP INDEX follows_follower_id_idx;
+CREATE INDEX follows_followee_id_idx ON follows(followee_id, created_at);
+CREATE INDEX follows_follower_id_idx ON follows(follower_id, created_at);
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE refresh_token
+ADD family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+ADD replaced_by TEXT DEFAULT NULL;
+CREATE INDEX refresh_token_family_id_idx ON refresh_token(family_id);
+-- +goose Down
+DROP INDEX refresh_token_family_id_idx;
+ALTER TABLE refresh_token
+DROP family_id,
+DROP replaced_by;
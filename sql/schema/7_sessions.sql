@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE refresh_token
+ADD user_agent TEXT NOT NULL DEFAULT '',
+ADD ip TEXT NOT NULL DEFAULT '',
+ADD last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+CREATE INDEX refresh_token_user_id_idx ON refresh_token(user_id);
+-- +goose Down
+DROP INDEX refresh_token_user_id_idx;
+ALTER TABLE refresh_token
+DROP user_agent,
+DROP ip,
+DROP last_used_at;
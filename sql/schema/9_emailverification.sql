@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE users
+ADD verified_at TIMESTAMP DEFAULT NULL;
+CREATE TABLE email_verification_tokens(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP DEFAULT NULL
+);
+-- +goose Down
+DROP TABLE email_verification_tokens;
+ALTER TABLE users
+DROP verified_at;
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth    = 10
+	maxThreadDepth        = 50
+	defaultThreadPageSize = 50
+	maxThreadPageSize     = 200
+)
+
+// ThreadChirp is a chirp in a conversation tree. Depth counts the replies
+// between it and the chirp the thread was requested for.
+type ThreadChirp struct {
+	Chirp
+	Depth int32 `json:"depth"`
+}
+
+// removeChirp deletes chirp with its rechirps and media. A chirp with
+// replies is replaced by a tombstone instead, so the conversation below
+// it stays connected; tombstones left without replies are deleted.
+// Quotes keep pointing at the deleted chirp and show it as unavailable.
+func (cfg *apiConfig) removeChirp(ctx context.Context, chirp database.Chirp) error {
+	tx, err := cfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DB.WithTx(tx)
+	// Replies may have arrived since chirp was read; the lock keeps new
+	// ones out until the deletion is done.
+	locked, err := qtx.LockChirp(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+	if err := qtx.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+		return err
+	}
+	media, err := qtx.DeleteChirpMedia(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+	if locked.ReplyCount > 0 {
+		// Earlier bodies must go as well as the current one.
+		if err := qtx.DeleteChirpRevisions(ctx, chirp.ID); err != nil {
+			return err
+		}
+		if err := qtx.DeleteChirpEntities(ctx, chirp.ID); err != nil {
+			return err
+		}
+		if err := qtx.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+			return err
+		}
+		err = qtx.TombstoneChirp(ctx, chirp.ID)
+	} else {
+		err = qtx.DeleteChripyById(ctx, chirp.ID)
+	}
+	if err != nil {
+		return err
+	}
+	// A tombstone only holds up its replies; one left without any goes
+	// too, and so on up the conversation.
+	parentID := locked.InReplyTo
+	for parentID.Valid {
+		parent, err := qtx.RemoveChirpReply(ctx, parentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !parent.DeletedAt.Valid || parent.ReplyCount > 0 {
+			break
+		}
+		if err := qtx.DeleteChripyById(ctx, parentID.UUID); err != nil {
+			return err
+		}
+		parentID = parent.InReplyTo
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, m := range media {
+		cfg.deleteBlob(ctx, m.Key)
+		cfg.deleteBlob(ctx, m.ThumbnailKey)
+	}
+	return nil
+}
+
+// getThread returns the conversation around a chirp: the chain of chirps
+// it replies to, and the replies below it in depth-first order. Limit the
+// depth with ?depth=, page with ?limit= and ?after=<next_after>.
+func (cfg *apiConfig) getThread(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	query := r.URL.Query()
+	params := database.GetThreadParams{
+		RootID:    id,
+		MaxDepth:  defaultThreadDepth,
+		MaxChirps: defaultThreadPageSize,
+	}
+	if value := query.Get("depth"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 || depth > maxThreadDepth {
+			respondWithError(w, http.StatusBadRequest, "depth must be between 0 and 50")
+			return
+		}
+		params.MaxDepth = int32(depth)
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxThreadPageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		params.MaxChirps = int32(limit)
+	}
+	if value := query.Get("after"); value != "" {
+		after, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+			return
+		}
+		params.AfterID = uuid.NullUUID{UUID: after, Valid: true}
+	}
+
+	filter, err := cfg.viewerFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread")
+		return
+	}
+	if _, err := cfg.DB.GetChirpById(r.Context(), id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Error retrieving chirp")
+		return
+	}
+	ancestors, err := cfg.DB.GetChirpAncestors(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread")
+		return
+	}
+	rows, err := cfg.DB.GetThread(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread")
+		return
+	}
+
+	chirps := append([]database.Chirp{}, ancestors...)
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	hydrated, err := cfg.hydrateChirps(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving thread")
+		return
+	}
+
+	resp := struct {
+		Ancestors []Chirp       `json:"ancestors"`
+		Chirps    []ThreadChirp `json:"chirps"`
+		// NextAfter continues the listing; it is nil on the last page.
+		NextAfter *uuid.UUID `json:"next_after"`
+	}{Ancestors: filterChirps(filter, hydrated[:len(ancestors)], false), Chirps: []ThreadChirp{}}
+	for i, row := range rows {
+		chirp := hydrated[len(ancestors)+i]
+		if visible(filter, &chirp, false) {
+			resp.Chirps = append(resp.Chirps, ThreadChirp{Chirp: chirp, Depth: row.Depth})
+		}
+	}
+	if len(rows) == int(params.MaxChirps) {
+		resp.NextAfter = &rows[len(rows)-1].Chirp.ID
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
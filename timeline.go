@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/timeline"
+)
+
+const (
+	defaultTimelinePageSize = 50
+	maxTimelinePageSize     = 200
+)
+
+type Timeline struct {
+	Chirps []Chirp `json:"chirps"`
+	// NextCursor continues the timeline with ?cursor=; it is empty on
+	// the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// getTimeline returns the chirps of the users the caller follows and the
+// caller's own, newest first, without muted chirps. Most come from the
+// caller's precomputed timeline; chirps of popular users are merged in
+// from the chirps table.
+func (cfg *apiConfig) getTimeline(w http.ResponseWriter, r *http.Request) {
+	caller, err := cfg.authorize(r, auth.ScopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	query := r.URL.Query()
+	limit := defaultTimelinePageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxTimelinePageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+	var cursor timeline.Cursor
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = timeline.Decode(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+	createdAt, id := cursor.Bounds()
+
+	entries, err := cfg.DB.ListTimelineEntries(r.Context(), database.ListTimelineEntriesParams{
+		UserID:    caller.UserID,
+		CreatedAt: createdAt,
+		ID:        id,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline")
+		return
+	}
+	popular, err := cfg.DB.ListChirpsFromPopularFollowees(r.Context(), database.ListChirpsFromPopularFolloweesParams{
+		FollowerID:   caller.UserID,
+		MinFollowers: timeline.MaxFanOutFollowers,
+		CreatedAt:    createdAt,
+		ID:           id,
+		Limit:        int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline")
+		return
+	}
+	fannedOut := make([]database.Chirp, 0, len(entries))
+	for _, entry := range entries {
+		fannedOut = append(fannedOut, entry.Chirp)
+	}
+	pulled := make([]database.Chirp, 0, len(popular))
+	for _, row := range popular {
+		pulled = append(pulled, row.Chirp)
+	}
+	chirps := timeline.Merge(limit, fannedOut, pulled)
+	filter, err := cfg.loadFilter(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline")
+		return
+	}
+
+	resp := Timeline{}
+	hydrated, err := cfg.hydrateChirps(r.Context(), chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error retrieving timeline")
+		return
+	}
+	// Muted chirps are dropped after paging, so a page can be shorter
+	// than limit without being the last.
+	resp.Chirps = filterChirps(filter, hydrated, true)
+	if len(chirps) == limit {
+		resp.NextCursor = timeline.CursorOf(chirps[len(chirps)-1]).Encode()
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// fanOut adds chirp to its author's timeline and, unless the author is
+// popular, to the timelines of all followers.
+func fanOut(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	return q.FanOutChirp(ctx, database.FanOutChirpParams{
+		AuthorID:     chirp.UserID.UUID,
+		ChirpID:      chirp.ID,
+		CreatedAt:    chirp.CreatedAt,
+		MaxFollowers: timeline.MaxFanOutFollowers,
+	})
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LucaFe1337/Chipry/internal/audit"
+	"github.com/LucaFe1337/Chipry/internal/auth"
+	"github.com/LucaFe1337/Chipry/internal/database"
+	"github.com/LucaFe1337/Chipry/internal/oauth"
+	"github.com/google/uuid"
+)
+
+const maxPersonalAccessTokenDays = 365
+
+var errInsufficientScope = errors.New("token lacks the required scope")
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+func personalAccessTokenFromDB(pat database.PersonalAccessToken) PersonalAccessToken {
+	resp := PersonalAccessToken{
+		ID:        pat.ID,
+		Name:      pat.Name,
+		Scopes:    pat.Scopes,
+		CreatedAt: pat.CreatedAt,
+	}
+	if pat.LastUsedAt.Valid {
+		resp.LastUsedAt = &pat.LastUsedAt.Time
+	}
+	if pat.ExpiresAt.Valid {
+		resp.ExpiresAt = &pat.ExpiresAt.Time
+	}
+	return resp
+}
+
+// principal is the user a request acts for. Scopes is nil for a full login
+// session and lists the granted scopes for a personal access token or a
+// token issued to an OAuth client.
+type principal struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	Scopes    []string
+}
+
+func (p principal) can(scope string) bool {
+	return p.Scopes == nil || auth.HasScope(p.Scopes, scope)
+}
+
+// authorize authenticates the request with an access token, a personal
+// access token or an OAuth access token and checks that it grants scope.
+func (cfg *apiConfig) authorize(r *http.Request, scope string) (principal, error) {
+	token_string, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return principal{}, err
+	}
+	var caller principal
+	if auth.IsPersonalAccessToken(token_string) {
+		pat, err := cfg.DB.GetPersonalAccessTokenByHash(r.Context(), auth.HashToken(token_string))
+		if err != nil {
+			return principal{}, err
+		}
+		if pat.RevokedAt.Valid {
+			return principal{}, errors.New("token has been revoked")
+		}
+		if pat.ExpiresAt.Valid && time.Now().After(pat.ExpiresAt.Time) {
+			return principal{}, errors.New("token has expired")
+		}
+		if err := cfg.DB.TouchPersonalAccessToken(r.Context(), pat.ID); err != nil {
+			log.Printf("error recording use of token %s: %v", pat.ID, err)
+		}
+		caller = principal{UserID: pat.UserID, Scopes: pat.Scopes}
+	} else {
+		claims, err := cfg.Keys.ParseClaims(token_string)
+		if err != nil {
+			return principal{}, err
+		}
+		userID, _ := claims.UserID()
+		caller = principal{UserID: userID, SessionID: sessionID(claims)}
+		if claims.ClientID != "" {
+			if err := cfg.checkOAuthTokenRevoked(r, claims); err != nil {
+				return principal{}, err
+			}
+			caller.Scopes = oauth.ParseScope(claims.Scope)
+		}
+	}
+	if !caller.can(scope) {
+		return principal{}, errInsufficientScope
+	}
+	return caller, nil
+}
+
+// respondWithAuthError answers a failed authorize call.
+func respondWithAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInsufficientScope) {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Token invalid")
+}
+
+// createPersonalAccessToken issues a new token. The plain token is only
+// part of this response; afterwards just its hash is known. Token
+// management requires a login session, so a leaked token can't mint more.
+func (cfg *apiConfig) createPersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	decoder := json.NewDecoder(r.Body)
+	param := parameters{}
+	err = decoder.Decode(&param)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	if param.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := auth.ValidateScopes(param.Scopes); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if param.ExpiresInDays < 0 || param.ExpiresInDays > maxPersonalAccessTokenDays {
+		respondWithError(w, http.StatusBadRequest, "expires_in_days must be between 0 and 365")
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if param.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, param.ExpiresInDays), Valid: true}
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error generating token")
+		return
+	}
+	userID, _ := claims.UserID()
+	pat, err := cfg.DB.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		Name:      param.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    param.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error saving token")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.PersonalTokenCreate,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]interface{}{"token_id": pat.ID, "name": pat.Name, "scopes": pat.Scopes},
+	})
+	resp := personalAccessTokenFromDB(pat)
+	resp.Token = token
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) listPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	userID, _ := claims.UserID()
+	rows, err := cfg.DB.ListPersonalAccessTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving tokens")
+		return
+	}
+	tokens := []PersonalAccessToken{}
+	for _, row := range rows {
+		tokens = append(tokens, personalAccessTokenFromDB(row))
+	}
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (cfg *apiConfig) revokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	claims, err := cfg.authenticate(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Token invalid")
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing ID!")
+		return
+	}
+	userID, _ := claims.UserID()
+	revoked, err := cfg.DB.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking token")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "token not found")
+		return
+	}
+	cfg.audit(r, audit.Event{
+		Type:      audit.TokenRevoke,
+		ActorID:   userID,
+		SubjectID: userID,
+		Data:      map[string]string{"kind": "personal_access_token", "token_id": id.String()},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}